@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ArgError is returned by the Arg helpers if a positional argument is
+// missing or cannot be parsed as the expected type.
+type ArgError struct {
+	// Index is the zero-based index of the argument.
+	Index int
+	// Value is the argument, empty if it is missing.
+	Value string
+	// Type is the expected type, e.g. "integer".
+	Type string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ArgError) Error() string {
+	if errors.Is(e.Err, errMissingArg) {
+		return fmt.Sprintf("argument %d: missing %s", e.Index+1, e.Type)
+	}
+	return fmt.Sprintf("argument %d: invalid %s %q: %v", e.Index+1, e.Type, e.Value, e.Err)
+}
+
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ArgError) ExitCode() int {
+	return ExitUsage
+}
+
+var errMissingArg = errors.New("missing argument")
+
+// ArgInt parses the i-th positional argument as an integer. Like Go integer
+// literals, it may have a base prefix, e.g. `0x1f`.
+func ArgInt(args []string, i int) (int, error) {
+	return parseArg(args, i, "integer", func(s string) (int, error) {
+		n, err := strconv.ParseInt(s, 0, strconv.IntSize)
+		return int(n), unwrapNumError(err)
+	})
+}
+
+// ArgDuration parses the i-th positional argument as a duration, e.g. `1m30s`.
+func ArgDuration(args []string, i int) (time.Duration, error) {
+	return parseArg(args, i, "duration", func(s string) (time.Duration, error) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			// the error of time.ParseDuration repeats the input
+			return 0, errors.New("expected a value like 1m30s")
+		}
+		return d, nil
+	})
+}
+
+// ArgBool parses the i-th positional argument as a boolean, accepting the
+// values supported by strconv.ParseBool.
+func ArgBool(args []string, i int) (bool, error) {
+	return parseArg(args, i, "boolean", func(s string) (bool, error) {
+		b, err := strconv.ParseBool(s)
+		return b, unwrapNumError(err)
+	})
+}
+
+func parseArg[T any](args []string, i int, typ string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	if i < 0 || i >= len(args) {
+		return zero, &ArgError{Index: i, Type: typ, Err: errMissingArg}
+	}
+	v, err := parse(args[i])
+	if err != nil {
+		return zero, &ArgError{Index: i, Value: args[i], Type: typ, Err: err}
+	}
+	return v, nil
+}
+
+// unwrapNumError strips the function name and input from strconv errors,
+// which ArgError reports itself.
+func unwrapNumError(err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return numErr.Err
+	}
+	return err
+}
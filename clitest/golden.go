@@ -0,0 +1,101 @@
+// Package clitest provides helpers for testing commands.
+package clitest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// AssertGolden compares got to the contents of the golden file at
+// goldenPath and fails the test with a line diff if they differ. If update is
+// set, the golden file is written with got instead, typically controlled by
+// a test flag:
+//
+//	var update = flag.Bool("update", false, "update golden files")
+//
+//	func TestHelp(t *testing.T) {
+//		stdout, _, _ := cmd.RunCaptured(context.Background(), []string{"--help"})
+//		clitest.AssertGolden(t, []byte(stdout), "testdata/help.golden", *update)
+//	}
+func AssertGolden(t testing.TB, got []byte, goldenPath string, update bool) {
+	t.Helper()
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("read golden file: %v (run with update set to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match %s (-want +got):\n%s", goldenPath, diffLines(string(want), string(got)))
+	}
+}
+
+// diffLines returns a line diff of a and b, marking lines only in a with `-`
+// and lines only in b with `+`.
+func diffLines(a, b string) string {
+	x := strings.SplitAfter(a, "\n")
+	y := strings.SplitAfter(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	line := func(prefix string, s string) {
+		if s == "" {
+			return
+		}
+		sb.WriteString(prefix)
+		sb.WriteString(strings.TrimSuffix(s, "\n"))
+		if !strings.HasSuffix(s, "\n") {
+			sb.WriteString(" (no newline at end)")
+		}
+		sb.WriteString("\n")
+	}
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			line("  ", x[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			line("- ", x[i])
+			i++
+		default:
+			line("+ ", y[j])
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		line("- ", x[i])
+	}
+	for ; j < len(y); j++ {
+		line("+ ", y[j])
+	}
+	return sb.String()
+}
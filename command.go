@@ -5,22 +5,159 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
+// ExecFunc is the function executing a command with its positional
+// arguments.
+type ExecFunc func(ctx context.Context, args []string) error
+
+// Middleware wraps an ExecFunc to add behavior around it.
+type Middleware func(next ExecFunc) ExecFunc
+
 type Command struct {
 	Name       string
+	Aliases    []string
 	ShortHelp  string
 	ShortUsage string
 	LongHelp   string
+	Examples   string
+
+	// PositionalArgs names the arguments the command expects. If set, the
+	// number of arguments is validated during parsing.
+	PositionalArgs []string
 
 	Flags *flag.FlagSet
-	Exec  func(ctx context.Context, args []string) error
+	Exec  ExecFunc
+
+	// Validate, if set, is called by Run with the positional arguments
+	// before Exec, e.g. to check constraints between flags. If it returns an
+	// error, Exec is not run and the error is returned as is; wrap it in a
+	// UsageError to mark it as a misuse. It runs after the validation done
+	// by Parse, e.g. of PositionalArgs, and after the PersistentPreRun hooks.
+	Validate ExecFunc
+
+	// PersistentFlags are flags of the command that are also accepted by
+	// all its subcommands. Help lists them as global options of the
+	// subcommands, unless a subcommand defines a flag of the same name.
+	PersistentFlags *flag.FlagSet
+
+	// DisableFlagParsing passes all arguments to Exec as they are, e.g. to
+	// forward them to another program. Neither flags nor subcommands are
+	// parsed and `-h`/`--help` do not show help, but Exec can still return
+	// ErrShowHelp.
+	DisableFlagParsing bool
+
+	// IgnoreUnknownFlags passes flags that are not defined in Flags on to
+	// Exec instead of failing, e.g. to forward them to another program.
+	// Unknown flags precede the remaining arguments, in the order they were
+	// given. Since it is not known whether they take a value, their values
+	// must be attached with `=`, as in `--foo=bar`; otherwise the value ends
+	// flag parsing like any other positional argument. Defined flags are
+	// parsed as usual. If a subcommand is selected, the unknown flags are
+	// passed on to the Exec of the command that is eventually run, ahead of
+	// its own arguments.
+	IgnoreUnknownFlags bool
+
+	// EnvVars declares inputs that are read from the environment only,
+	// e.g. secrets that should not be passed on the command line.
+	EnvVars []EnvVar
+
+	// Stdout and Stderr are the writers made available to Exec through the
+	// context. If nil, the parent's writers or os.Stdout and os.Stderr are
+	// used.
+	Stdout io.Writer
+	Stderr io.Writer
 
 	Subcommands []*Command
 
+	// Hidden omits the command from help, documentation, completions and
+	// command listings. Unlike a disabled command, it can still be invoked.
+	Hidden bool
+
+	// Deprecated, if set, marks the command and all its subcommands as
+	// deprecated. Invoking any of them adds a warning with the message to
+	// those reported by Warnings, unless a subcommand sets its own message.
+	Deprecated string
+
+	// Enabled, if set, reports whether the command is available. A disabled
+	// command is neither listed in help, documentation or completions nor
+	// can it be invoked, as if it were not among its parent's subcommands.
+	// The predicate is not cached but evaluated whenever the subcommands are
+	// looked up or listed, e.g. once per Parse.
+	Enabled func() bool
+
+	// RequireSubcommand marks the command as a group that can only be
+	// invoked through one of its subcommands.
+	RequireSubcommand bool
+
+	// UnknownCommandHandler, if set, is run instead of Exec when the first
+	// positional argument matches none of the subcommands. It receives the
+	// unmatched name and the remaining arguments, e.g. to run external
+	// plugins. Unknown flags passed on due to IgnoreUnknownFlags precede the
+	// remaining arguments.
+	UnknownCommandHandler func(ctx context.Context, name string, args []string) error
+
+	// Timeout bounds the execution of the command, including any selected
+	// subcommand. A zero value means no timeout.
+	Timeout time.Duration
+
+	// FlagCompletions provides shell completion candidates for the values of
+	// flags by name. Flags with an EnumValue complete to its allowed values
+	// without an entry.
+	FlagCompletions map[string]FlagCompletionFunc
+
+	// PersistentPreRun runs before the command and any selected subcommand,
+	// parents before children. The returned context, if not nil, is passed
+	// down, e.g. to provide a resource through a ContextKey.
+	PersistentPreRun func(ctx context.Context, args []string) (context.Context, error)
+
+	// PersistentPostRun runs after the command and any selected subcommand,
+	// children before parents. It runs exactly once if PersistentPreRun did
+	// not fail, even if execution did, so it can release resources.
+	PersistentPostRun func(ctx context.Context, args []string) error
+
 	selected *Command
 	parent   *Command
 	args     []string
+	opts     ParseOptions
+
+	warnings      []string
+	envValues     map[string]string
+	helpRequested bool
+	helpJSON      bool
+	sources       map[string]FlagSource
+	completion    []string
+	unknownFlags  []string
+
+	// inheritedFlags are the names of the flags added from the persistent
+	// flags of the command's parents
+	inheritedFlags map[string]bool
+
+	middlewares           []Middleware
+	persistentMiddlewares []Middleware
+}
+
+// NewCommand creates a command whose flags are bound to a configuration
+// value. The register function defines the flags on the command's flag set
+// and returns the value they are bound to, which is passed to exec once the
+// flags are parsed.
+func NewCommand[T any](name string, register func(*flag.FlagSet) *T, exec func(ctx context.Context, cfg *T, args []string) error) *Command {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	cfg := register(fs)
+
+	return &Command{
+		Name:  name,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			return exec(ctx, cfg, args)
+		},
+	}
 }
 
 func (cmd *Command) Run(ctx context.Context) (err error) {
@@ -32,18 +169,395 @@ func (cmd *Command) Run(ctx context.Context) (err error) {
 		return errors.New("none selected")
 	}
 
+	if cmd.completion != nil {
+		return cmd.writeCompletions(ctx, cmd.stdout(), cmd.completion)
+	}
+
+	if cmd.opts.interruptHandling && cmd.parent == nil {
+		var stop func()
+		ctx, stop = cmd.handleInterrupts(ctx)
+		defer stop()
+	}
+
+	if cmd.opts.debugInvocationEnabled && cmd.chainFlag("debug-invocation") {
+		return cmd.writeInvocation(cmd.stdout())
+	}
+
+	if cmd.opts.configDumpEnabled && testFlag(cmd.Flags, "config-dump") {
+		return cmd.resolved().writeConfigDump(cmd.stdout())
+	}
+
+	if cmd.opts.dryRunEnabled && testFlag(cmd.Flags, "dry-run") {
+		ctx = withDryRun(ctx)
+	}
+
+	if cmd.opts.assumeYesEnabled && testFlag(cmd.Flags, "yes") {
+		ctx = withAssumeYes(ctx)
+	}
+
+	if cmd.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cmd.Timeout)
+		defer cancel()
+		defer func() {
+			if err == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("%s: %w", cmd.Name, ctx.Err())
+			}
+		}()
+	}
+
+	if cmd.opts.startupVersion != nil && cmd.parent == nil {
+		logVersion(ctx, cmd.opts.startupVersion)
+	}
+
+	if cmd.opts.phaseTraceEnabled && phaseTimingsFrom(ctx) == nil && cmd.chainFlag("trace") {
+		pt := &phaseTimings{}
+		ctx = context.WithValue(ctx, phaseTimingsKey{}, pt)
+		defer func() { fmt.Fprintln(cmd.stderr(), pt) }()
+	}
+	pt := phaseTimingsFrom(ctx)
+
+	if cmd.PersistentPreRun != nil {
+		start := time.Now()
+		hctx, err := cmd.PersistentPreRun(withWriters(ctx, cmd.stdout(), cmd.stderr()), cmd.Args())
+		pt.add(phasePre, start)
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.Name, err)
+		}
+		if hctx != nil {
+			ctx = hctx
+		}
+	}
+	if cmd.PersistentPostRun != nil {
+		hctx := ctx
+		defer func() {
+			start := time.Now()
+			perr := cmd.PersistentPostRun(withWriters(hctx, cmd.stdout(), cmd.stderr()), cmd.Args())
+			pt.add(phasePost, start)
+			if perr != nil {
+				err = errors.Join(err, fmt.Errorf("%s: %w", cmd.Name, perr))
+			}
+		}()
+	}
+
 	switch {
+	case cmd.selected == cmd && cmd.handlesUnknownCommand():
+		ctx = withWriters(ctx, cmd.stdout(), cmd.stderr())
+		ctx = withFlags(ctx, cmd.Flags)
+		ctx = withCommand(ctx, cmd)
+		defer pt.add(phaseExec, time.Now())
+		args := cmd.positionalArgs()
+		return cmd.UnknownCommandHandler(ctx, args[0], append(slices.Clone(cmd.unknownFlags), args[1:]...))
 	case cmd.selected == cmd && cmd.Exec == nil:
 		return fmt.Errorf("%s: %w", cmd.Name, errors.New("no exec function"))
 	case cmd.selected == cmd && cmd.Exec != nil:
 		defer func() {
+			var usageErr *UsageError
 			if errors.Is(err, flag.ErrHelp) {
-				cmd.Flags.Usage()
+				cmd.printHelp(cmd.stdout())
 				err = nil
+			} else if cmd.opts.usageOnError && errors.As(err, &usageErr) {
+				cmd.printHelp(cmd.stderr())
 			}
 		}()
-		return cmd.Exec(ctx, cmd.args)
+		ctx = withWriters(ctx, cmd.stdout(), cmd.stderr())
+		ctx = withFlags(ctx, cmd.Flags)
+		ctx = withCommand(ctx, cmd)
+		if cmd.Validate != nil {
+			if err := cmd.Validate(ctx, cmd.args); err != nil {
+				return err
+			}
+		}
+		defer pt.add(phaseExec, time.Now())
+		return cmd.exec()(ctx, cmd.args)
 	default:
 		return cmd.selected.Run(ctx)
 	}
 }
+
+// SetFlags returns the names and values of the flags that were explicitly
+// set while parsing the command, including persistent flags inherited from
+// its parents that were set on any of them.
+func (cmd *Command) SetFlags() map[string]string {
+	set := map[string]string{}
+	if cmd.Flags != nil {
+		cmd.Flags.Visit(func(f *flag.Flag) {
+			set[f.Name] = f.Value.String()
+		})
+	}
+	for p := cmd.parent; p != nil; p = p.parent {
+		if p.Flags == nil {
+			continue
+		}
+		p.Flags.Visit(func(f *flag.Flag) {
+			if _, ok := set[f.Name]; !ok && cmd.inheritedFlags[f.Name] {
+				set[f.Name] = f.Value.String()
+			}
+		})
+	}
+	return set
+}
+
+// Use adds middlewares wrapping the command's Exec function. They are
+// applied in order, the first one being the outermost.
+func (cmd *Command) Use(mw ...Middleware) {
+	cmd.middlewares = append(cmd.middlewares, mw...)
+}
+
+// UsePersistent is like Use but the middlewares also wrap the Exec functions
+// of all subcommands. Middlewares of parent commands wrap those of their
+// subcommands.
+func (cmd *Command) UsePersistent(mw ...Middleware) {
+	cmd.persistentMiddlewares = append(cmd.persistentMiddlewares, mw...)
+}
+
+// exec returns the command's Exec function wrapped in all middlewares that
+// apply to it.
+func (cmd *Command) exec() ExecFunc {
+	var chain []Middleware
+	for c := cmd.parent; c != nil; c = c.parent {
+		chain = append(slices.Clone(c.persistentMiddlewares), chain...)
+	}
+	chain = append(chain, cmd.persistentMiddlewares...)
+	chain = append(chain, cmd.middlewares...)
+
+	exec := cmd.Exec
+	for i := len(chain) - 1; i >= 0; i-- {
+		exec = chain[i](exec)
+	}
+	return exec
+}
+
+// handlesUnknownCommand reports whether the parsed arguments are to be
+// passed to the UnknownCommandHandler.
+func (cmd *Command) handlesUnknownCommand() bool {
+	args := cmd.positionalArgs()
+	return cmd.UnknownCommandHandler != nil && len(args) > 0 && cmd.lookupSubcommand(args[0]) == nil
+}
+
+// positionalArgs returns the arguments of the command without the unknown
+// flags that precede them, see IgnoreUnknownFlags.
+func (cmd *Command) positionalArgs() []string {
+	return cmd.args[len(cmd.unknownFlags):]
+}
+
+// Path returns the names of the command's parents and the command itself,
+// separated by spaces, e.g. `app remote add`.
+func (cmd *Command) Path() string {
+	path := cmd.Name
+	for p := cmd.parent; p != nil; p = p.parent {
+		path = p.Name + " " + path
+	}
+	return path
+}
+
+// Args returns the positional arguments of the command selected by Parse,
+// i.e. the arguments remaining after flags and subcommand names have been
+// consumed.
+func (cmd *Command) Args() []string {
+	return cmd.resolved().args
+}
+
+// resolved returns the command selected by Parse, following the chain of
+// selected subcommands.
+func (cmd *Command) resolved() *Command {
+	c := cmd
+	for c.selected != nil && c.selected != c {
+		c = c.selected
+	}
+	return c
+}
+
+// LookupInheritedFlag returns the flag with the given name, looking at the
+// command's own flags first and then at those of its parents. It returns nil
+// if no command in the chain defines the flag.
+func (cmd *Command) LookupInheritedFlag(name string) *flag.Flag {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Flags == nil {
+			continue
+		}
+		if f := c.Flags.Lookup(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// hiddenBelow reports whether the command or any of its parents below root
+// is hidden.
+func (cmd *Command) hiddenBelow(root *Command) bool {
+	for c := cmd; c != nil && c != root; c = c.parent {
+		if c.Hidden {
+			return true
+		}
+	}
+	return false
+}
+
+// chainFlag reports whether the named bool flag is set on the command or any
+// of the selected subcommands.
+func (cmd *Command) chainFlag(name string) bool {
+	for c := cmd; c != nil; c = c.selected {
+		if testFlag(c.Flags, name) {
+			return true
+		}
+		if c.selected == c {
+			break
+		}
+	}
+	return false
+}
+
+// enabled reports whether the command is available, see Enabled.
+func (cmd *Command) enabled() bool {
+	return cmd.Enabled == nil || cmd.Enabled()
+}
+
+// Find resolves a command path like Parse does, without parsing flags or
+// running anything. It returns the deepest command named by the leading
+// args, matching names and aliases, and the remaining args, which start at
+// the first argument that does not name a subcommand.
+//
+// Like Parse, it returns an UnknownCommandError if the remaining args start
+// with an unknown name for a command with RequireSubcommand and no
+// UnknownCommandHandler. The deepest
+// matched command is returned along with the error.
+func (cmd *Command) Find(args []string) (*Command, []string, error) {
+	c := cmd
+	for len(args) > 0 {
+		sub := c.lookupSubcommand(args[0])
+		if sub == nil {
+			break
+		}
+		sub.parent = c
+		c, args = sub, args[1:]
+	}
+
+	if c.RequireSubcommand && c.UnknownCommandHandler == nil && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return c, args, fmt.Errorf("%s: %w", c.Name, c.unknownCommandError(args[0]))
+	}
+	return c, args, nil
+}
+
+// Walk calls fn for the command and all its enabled subcommands in
+// depth-first order. If fn returns an error, the walk stops and the error is
+// returned.
+func (cmd *Command) Walk(fn func(*Command) error) error {
+	if err := fn(cmd); err != nil {
+		return err
+	}
+	for _, subcmd := range cmd.Subcommands {
+		if !subcmd.enabled() {
+			continue
+		}
+		subcmd.parent = cmd
+		if err := subcmd.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunCaptured parses the arguments and runs the command with the output
+// writers of the whole command tree redirected to buffers. It returns what
+// was written to them. The original writers are restored before returning.
+func (cmd *Command) RunCaptured(ctx context.Context, args []string, options ...ParseOption) (stdout string, stderr string, err error) {
+	var (
+		mu           sync.Mutex
+		outBuf       strings.Builder
+		errBuf       strings.Builder
+		outW         = &lockedWriter{mu: &mu, w: &outBuf}
+		errW         = &lockedWriter{mu: &mu, w: &errBuf}
+		savedWriters = map[*Command][2]io.Writer{}
+	)
+
+	_ = cmd.Walk(func(c *Command) error {
+		savedWriters[c] = [2]io.Writer{c.Stdout, c.Stderr}
+		c.Stdout, c.Stderr = outW, errW
+		return nil
+	})
+	defer func() {
+		for c, w := range savedWriters {
+			c.Stdout, c.Stderr = w[0], w[1]
+		}
+	}()
+
+	if err = cmd.Parse(args, options...); err == nil {
+		err = cmd.Run(ctx)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return outBuf.String(), errBuf.String(), err
+}
+
+func (cmd *Command) stdout() io.Writer {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Stdout != nil {
+			return c.Stdout
+		}
+	}
+	return os.Stdout
+}
+
+func (cmd *Command) stderr() io.Writer {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Stderr != nil {
+			return c.Stderr
+		}
+	}
+	return os.Stderr
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+// RunParallel parses and runs each command with the corresponding arguments
+// concurrently, running at most limit commands at a time. A limit less than
+// one means no limit. The commands must be distinct values. All errors are
+// joined into the returned error.
+func RunParallel(ctx context.Context, cmds []*Command, args [][]string, limit int, options ...ParseOption) error {
+	if len(cmds) != len(args) {
+		return fmt.Errorf("got %d commands but %d argument lists", len(cmds), len(args))
+	}
+	if limit < 1 {
+		limit = len(cmds)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, limit)
+		errs = make([]error, len(cmds))
+	)
+	for i, cmd := range cmds {
+		wg.Add(1)
+		go func(i int, cmd *Command) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("%s: %w", cmd.Name, ctx.Err())
+				return
+			}
+
+			if err := cmd.Parse(args[i], options...); err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = cmd.Run(ctx)
+		}(i, cmd)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const completeCommandName = "__complete"
+
+// FlagCompletionFunc returns the completion candidates for a flag value that
+// starts with prefix. Candidates not starting with prefix are ignored.
+type FlagCompletionFunc func(ctx context.Context, prefix string) []string
+
+// writeCompletions writes the completion candidates for the last of args,
+// one per line.
+func (cmd *Command) writeCompletions(ctx context.Context, w io.Writer, args []string) error {
+	for _, c := range cmd.complete(ctx, args) {
+		if _, err := fmt.Fprintln(w, c); err != nil {
+			return fmt.Errorf("%s: %w", cmd.Name, err)
+		}
+	}
+	return nil
+}
+
+// complete returns the completion candidates for the last of args, which is
+// the word being completed, given the words before it.
+func (cmd *Command) complete(ctx context.Context, args []string) []string {
+	cur := ""
+	if len(args) > 0 {
+		cur = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	c := cmd
+	var pending *flag.Flag
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		pending = nil
+		switch {
+		case arg == "--":
+			// only positional arguments follow
+			return nil
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			name := strings.TrimLeft(arg, "-")
+			if strings.Contains(name, "=") {
+				continue
+			}
+			f := c.LookupInheritedFlag(name)
+			if f == nil || isBoolFlag(f) {
+				continue
+			}
+			// shells splitting words at `=` pass it as a separate word
+			if i+1 < len(args) && args[i+1] == "=" {
+				i++
+			}
+			if i+1 == len(args) {
+				pending = f
+			} else {
+				i++
+			}
+		default:
+			if sub := c.lookupSubcommand(arg); sub != nil {
+				sub.parent = c
+				c = sub
+			}
+		}
+	}
+
+	if pending != nil {
+		return c.completeFlagValue(ctx, pending, "", cur)
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		if name, value, ok := strings.Cut(cur, "="); ok {
+			f := c.LookupInheritedFlag(strings.TrimLeft(name, "-"))
+			if f == nil {
+				return nil
+			}
+			return c.completeFlagValue(ctx, f, name+"=", value)
+		}
+		return c.completeFlagNames(cur)
+	}
+
+	var candidates []string
+	for _, sub := range c.helpSubcommands() {
+		if strings.HasPrefix(sub.Name, cur) {
+			candidates = append(candidates, sub.Name)
+		}
+	}
+	return candidates
+}
+
+func (c *Command) completeFlagNames(prefix string) []string {
+	var candidates []string
+	for p := c; p != nil; p = p.parent {
+		visitAll(p.Flags, func(f *flag.Flag) {
+			name := "--" + f.Name
+			if len(f.Name) == 1 {
+				name = "-" + f.Name
+			}
+			if strings.HasPrefix(name, prefix) && !slices.Contains(candidates, name) {
+				candidates = append(candidates, name)
+			}
+		})
+	}
+	return candidates
+}
+
+// completeFlagValue returns the candidates for the value of f starting with
+// prefix, each preceded by lead.
+func (c *Command) completeFlagValue(ctx context.Context, f *flag.Flag, lead string, prefix string) []string {
+	var values []string
+	if fn := c.flagCompletion(f.Name); fn != nil {
+		values = fn(ctx, prefix)
+	} else if e, ok := f.Value.(interface{ Allowed() []string }); ok {
+		values = e.Allowed()
+	}
+
+	var candidates []string
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			candidates = append(candidates, lead+v)
+		}
+	}
+	return candidates
+}
+
+func (c *Command) flagCompletion(name string) FlagCompletionFunc {
+	for p := c; p != nil; p = p.parent {
+		if fn, ok := p.FlagCompletions[name]; ok {
+			return fn
+		}
+	}
+	return nil
+}
+
+// GenCompletion writes a completion script for the given shell, one of
+// "bash", "zsh" or "fish", to w. The script queries the candidates from the
+// command itself, so it must be parsed with WithCompletion.
+func (c *Command) GenCompletion(w io.Writer, shell string) error {
+	var tmpl string
+	switch shell {
+	case "bash":
+		tmpl = bashCompletion
+	case "zsh":
+		tmpl = zshCompletion
+	case "fish":
+		tmpl = fishCompletion
+	default:
+		return fmt.Errorf("%s: unsupported shell: %q", c.Name, shell)
+	}
+
+	fn := strings.NewReplacer("-", "_", ".", "_").Replace(c.Name)
+	script := strings.NewReplacer("{{name}}", c.Name, "{{func}}", fn, "{{complete}}", completeCommandName).Replace(tmpl)
+	if _, err := io.WriteString(w, script); err != nil {
+		return fmt.Errorf("%s: %w", c.Name, err)
+	}
+	return nil
+}
+
+const bashCompletion = `# bash completion for {{name}}
+
+__{{func}}_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($("${COMP_WORDS[0]}" {{complete}} "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+
+complete -o default -F __{{func}}_complete {{name}}
+`
+
+const zshCompletion = `#compdef {{name}}
+
+__{{func}}_complete() {
+    local -a candidates
+    candidates=(${(f)"$("${words[1]}" {{complete}} "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -a candidates
+}
+
+compdef __{{func}}_complete {{name}}
+`
+
+const fishCompletion = `# fish completion for {{name}}
+
+complete -c {{name}} -f -a '({{name}} {{complete}} (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`
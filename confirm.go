@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Confirm writes prompt followed by " [y/N] " to out and reads a line from in.
+// It reports true for "y" or "yes" and false for "n", "no" or an empty line,
+// ignoring case. Any other answer prompts again. If the context carries an
+// assumed yes (see WithAssumeYes), Confirm reports true without prompting.
+//
+// Reading is abandoned when ctx is done, in which case the context's error is
+// returned.
+func Confirm(ctx context.Context, in io.Reader, out io.Writer, prompt string) (bool, error) {
+	if IsAssumeYes(ctx) {
+		return true, nil
+	}
+
+	type answer struct {
+		yes bool
+		err error
+	}
+	ch := make(chan answer, 1)
+
+	go func() {
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Fprintf(out, "%s [y/N] ", prompt)
+			line, err := readLine(in)
+			if err != nil && (err != io.EOF || line == "") {
+				if err == io.EOF {
+					err = nil
+				}
+				ch <- answer{false, err}
+				return
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				ch <- answer{true, nil}
+				return
+			case "", "n", "no":
+				ch <- answer{false, nil}
+				return
+			}
+			if err == io.EOF {
+				ch <- answer{false, nil}
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case a := <-ch:
+		return a.yes, a.err
+	}
+}
+
+// readLine reads from r one byte at a time up to and including '\n', so that
+// input following the line is left for subsequent reads.
+func readLine(r io.Reader) (string, error) {
+	var sb strings.Builder
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n > 0 {
+			sb.WriteByte(b[0])
+			if b[0] == '\n' {
+				return sb.String(), nil
+			}
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+	}
+}
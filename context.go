@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+)
+
+type (
+	dryRunKey    struct{}
+	assumeYesKey struct{}
+	stdoutKey    struct{}
+	stderrKey    struct{}
+	flagsKey     struct{}
+	commandKey   struct{}
+)
+
+// IsDryRun reports whether the `--dry-run` flag was set on the executed
+// command or any of its parents. See WithDryRun.
+func IsDryRun(ctx context.Context) bool {
+	v, _ := ctx.Value(dryRunKey{}).(bool)
+	return v
+}
+
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsAssumeYes reports whether the `--yes` flag was set on the executed
+// command or any of its parents. See WithAssumeYes.
+func IsAssumeYes(ctx context.Context) bool {
+	v, _ := ctx.Value(assumeYesKey{}).(bool)
+	return v
+}
+
+func withAssumeYes(ctx context.Context) context.Context {
+	return context.WithValue(ctx, assumeYesKey{}, true)
+}
+
+// Stdout returns the writer for standard output of the executed command.
+func Stdout(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(stdoutKey{}).(io.Writer); ok {
+		return w
+	}
+	return os.Stdout
+}
+
+// Stderr returns the writer for standard error of the executed command.
+func Stderr(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(stderrKey{}).(io.Writer); ok {
+		return w
+	}
+	return os.Stderr
+}
+
+func withWriters(ctx context.Context, stdout io.Writer, stderr io.Writer) context.Context {
+	ctx = context.WithValue(ctx, stdoutKey{}, stdout)
+	return context.WithValue(ctx, stderrKey{}, stderr)
+}
+
+// Flags returns the flag set of the executed command, allowing Exec
+// functions to read flag values without closing over the flag set.
+func Flags(ctx context.Context) *flag.FlagSet {
+	fs, _ := ctx.Value(flagsKey{}).(*flag.FlagSet)
+	return fs
+}
+
+func withFlags(ctx context.Context, fs *flag.FlagSet) context.Context {
+	return context.WithValue(ctx, flagsKey{}, fs)
+}
+
+func withCommand(ctx context.Context, cmd *Command) context.Context {
+	return context.WithValue(ctx, commandKey{}, cmd)
+}
+
+// OutputFormat returns the value of the `--format` flag for the executed
+// command. The flag is looked up on the command and its parents, and the
+// value of the nearest one that was set, from the command line or the
+// environment, is returned. Thus a `--format` defined on the root, e.g. as
+// one of its PersistentFlags, provides the format for all subcommands unless
+// they set their own. If no such flag was set, the default of the nearest one
+// is returned, or the empty string if there is none.
+func OutputFormat(ctx context.Context) string {
+	cmd, _ := ctx.Value(commandKey{}).(*Command)
+
+	var def *flag.Flag
+	for c := cmd; c != nil; c = c.parent {
+		if c.Flags == nil {
+			continue
+		}
+		f := c.Flags.Lookup("format")
+		if f == nil {
+			continue
+		}
+		if c.FlagSource(f.Name) != SourceDefault {
+			return f.Value.String()
+		}
+		if def == nil {
+			def = f
+		}
+	}
+
+	if def == nil {
+		return ""
+	}
+	return def.Value.String()
+}
+
+// ContextKey is a typed key for values passed through the context, e.g. a
+// resource set up in a PersistentPreRun and used by the Exec functions of
+// nested commands. Keys are compared by identity, so each key should be
+// created once with NewContextKey and stored in a package-level variable.
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewContextKey returns a new key for values of type T. The name is only
+// used for debugging.
+func NewContextKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{name: name}
+}
+
+// WithValue returns a copy of ctx carrying v under the key.
+func (k *ContextKey[T]) WithValue(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+// Value returns the value stored under the key and whether it was present.
+func (k *ContextKey[T]) Value(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+func (k *ContextKey[T]) String() string {
+	return "cli.ContextKey(" + k.name + ")"
+}
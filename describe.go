@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+)
+
+// commandDescription is the structured description of a command that the
+// generated documentation and dumps are based on.
+type commandDescription struct {
+	Name        string               `json:"name"`
+	Path        string               `json:"path"`
+	Aliases     []string             `json:"aliases,omitempty"`
+	ShortHelp   string               `json:"shortHelp,omitempty"`
+	LongHelp    string               `json:"longHelp,omitempty"`
+	Usage       string               `json:"usage"`
+	Examples    string               `json:"examples,omitempty"`
+	Flags       []flagDescription    `json:"flags,omitempty"`
+	Subcommands []commandDescription `json:"subcommands,omitempty"`
+}
+
+type flagDescription struct {
+	Name        string `json:"name"`
+	Placeholder string `json:"placeholder,omitempty"`
+	Usage       string `json:"usage,omitempty"`
+	Default     string `json:"default,omitempty"`
+}
+
+// DumpJSON returns a JSON description of the command and all its
+// subcommands, including their help texts, usage and flags.
+func (c *Command) DumpJSON() ([]byte, error) {
+	return json.MarshalIndent(describe(c, true), "", "  ")
+}
+
+// HelpJSON returns a JSON description of the command's help, including its
+// usage, flags, examples and subcommands. It is shown instead of the help
+// text if `--json` is given along with `-h` or `--help`.
+func (c *Command) HelpJSON() ([]byte, error) {
+	return json.MarshalIndent(describe(c, false), "", "  ")
+}
+
+// describe returns the description of the command, including those of its
+// subcommands if recursive is set.
+func describe(c *Command, recursive bool) commandDescription {
+	d := commandDescription{
+		Name:      c.Name,
+		Path:      c.Path(),
+		Aliases:   c.Aliases,
+		ShortHelp: c.ShortHelp,
+		LongHelp:  c.LongHelp,
+		Usage:     c.ShortUsage,
+		Examples:  c.Examples,
+	}
+	if d.Usage == "" {
+		d.Usage = DefaultShortUsage(c)
+	}
+
+	if c.Flags != nil {
+		c.Flags.VisitAll(func(f *flag.Flag) {
+			name, usage := flag.UnquoteUsage(f)
+			d.Flags = append(d.Flags, flagDescription{
+				Name:        f.Name,
+				Placeholder: name,
+				Usage:       usage,
+				Default:     f.DefValue,
+			})
+		})
+	}
+
+	for _, subcmd := range c.helpSubcommands() {
+		subcmd.parent = c
+		if recursive {
+			d.Subcommands = append(d.Subcommands, describe(subcmd, true))
+		} else {
+			d.Subcommands = append(d.Subcommands, commandDescription{
+				Name:      subcmd.Name,
+				Path:      d.Path + " " + subcmd.Name,
+				Aliases:   subcmd.Aliases,
+				ShortHelp: subcmd.ShortHelp,
+			})
+		}
+	}
+
+	return d
+}
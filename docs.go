@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DocWriteFunc receives a generated document and the relative path it
+// belongs at.
+type DocWriteFunc func(path string, content []byte) error
+
+// GenMarkdownTree writes a Markdown document for the command and each of its
+// subcommands into dir.
+func GenMarkdownTree(c *Command, dir string) error {
+	return GenMarkdownTreeFunc(c, dirWriter(dir))
+}
+
+// GenMarkdownTreeFunc generates a Markdown document for the command and each
+// of its subcommands and passes them to write.
+func GenMarkdownTreeFunc(c *Command, write DocWriteFunc) error {
+	return c.Walk(func(cmd *Command) error {
+		if cmd.hiddenBelow(c) {
+			return nil
+		}
+		d := describe(cmd, false)
+		return write(markdownFilename(d.Path), []byte(renderMarkdown(d)))
+	})
+}
+
+// GenManTree writes a man page for the command and each of its subcommands
+// into dir.
+func GenManTree(c *Command, dir string) error {
+	return GenManTreeFunc(c, dirWriter(dir))
+}
+
+// GenManTreeFunc generates a man page for the command and each of its
+// subcommands and passes them to write.
+func GenManTreeFunc(c *Command, write DocWriteFunc) error {
+	return c.Walk(func(cmd *Command) error {
+		if cmd.hiddenBelow(c) {
+			return nil
+		}
+		d := describe(cmd, false)
+		return write(manFilename(d.Path), []byte(renderMan(d)))
+	})
+}
+
+func dirWriter(dir string) DocWriteFunc {
+	return func(path string, content []byte) error {
+		return os.WriteFile(filepath.Join(dir, path), content, 0o644)
+	}
+}
+
+func markdownFilename(path string) string {
+	return strings.ReplaceAll(path, " ", "_") + ".md"
+}
+
+func manFilename(path string) string {
+	return strings.ReplaceAll(path, " ", "-") + ".1"
+}
+
+func renderMarkdown(d commandDescription) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", d.Path)
+	if d.ShortHelp != "" {
+		fmt.Fprintf(&b, "%s\n\n", d.ShortHelp)
+	}
+	fmt.Fprintf(&b, "```\n%s\n```\n\n", d.Usage)
+	if d.LongHelp != "" {
+		fmt.Fprintf(&b, "%s\n\n", d.LongHelp)
+	}
+
+	if len(d.Subcommands) > 0 {
+		fmt.Fprintf(&b, "## Commands\n\n")
+		for _, sub := range d.Subcommands {
+			fmt.Fprintf(&b, "* [%s](%s)", sub.Name, markdownFilename(sub.Path))
+			if sub.ShortHelp != "" {
+				fmt.Fprintf(&b, " - %s", sub.ShortHelp)
+			}
+			fmt.Fprintf(&b, "\n")
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	if len(d.Flags) > 0 {
+		fmt.Fprintf(&b, "## Options\n\n")
+		for _, f := range d.Flags {
+			fmt.Fprintf(&b, "* `%s`", docFlagName(f))
+			if f.Usage != "" {
+				fmt.Fprintf(&b, " - %s", f.Usage)
+			}
+			fmt.Fprintf(&b, "\n")
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func renderMan(d commandDescription) string {
+	var b strings.Builder
+
+	name := strings.ReplaceAll(d.Path, " ", "-")
+
+	fmt.Fprintf(&b, ".TH %q \"1\"\n", strings.ToUpper(name))
+	fmt.Fprintf(&b, ".SH NAME\n%s", manEscape(name))
+	if d.ShortHelp != "" {
+		fmt.Fprintf(&b, " \\- %s", manEscape(d.ShortHelp))
+	}
+	fmt.Fprintf(&b, "\n")
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n.B %s\n", manEscape(d.Usage))
+	if d.LongHelp != "" {
+		fmt.Fprintf(&b, ".SH DESCRIPTION\n%s\n", manEscape(d.LongHelp))
+	}
+
+	if len(d.Flags) > 0 {
+		fmt.Fprintf(&b, ".SH OPTIONS\n")
+		for _, f := range d.Flags {
+			fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", manEscape(docFlagName(f)), manEscape(f.Usage))
+		}
+	}
+
+	if len(d.Subcommands) > 0 {
+		fmt.Fprintf(&b, ".SH COMMANDS\n")
+		for _, sub := range d.Subcommands {
+			fmt.Fprintf(&b, ".TP\n.BR %s (1)\n%s\n", manEscape(strings.ReplaceAll(sub.Path, " ", "-")), manEscape(sub.ShortHelp))
+		}
+	}
+
+	return b.String()
+}
+
+func docFlagName(f flagDescription) string {
+	name := "--" + f.Name
+	if len(f.Name) == 1 {
+		name = "-" + f.Name
+	}
+	if f.Placeholder != "" {
+		name += " " + f.Placeholder
+	}
+	return name
+}
+
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// ErrShowHelp can be returned by an Exec function to request the command's
+// help to be shown. It is identical to flag.ErrHelp, so checks against either
+// sentinel keep working.
+var ErrShowHelp = flag.ErrHelp
+
+// ErrSilent can be returned, or wrapped, by an Exec function that already
+// reported its error itself. HandleError prints nothing for it but still
+// returns a failing exit code, which is ExitFailure unless the error carries
+// another one, e.g. `cli.WithExitCode(cli.ErrSilent, 3)`.
+var ErrSilent = errors.New("silent error")
+
+// Exit codes for the outcomes distinguished by ExitCode.
+const (
+	// ExitOK is used on success, including when help was requested.
+	ExitOK = 0
+	// ExitFailure is used for operational failures.
+	ExitFailure = 1
+	// ExitUsage is used for misuse of a command, including when help was
+	// shown because of a problem with the invocation.
+	ExitUsage = 2
+)
+
+// ExitCoder is implemented by errors that determine the process exit code.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// ExitCode returns the process exit code for an error returned by Parse or
+// Run. It is ExitOK for nil and for requested help, the code of the first
+// ExitCoder in the error's chain, or ExitFailure otherwise.
+//
+// Help that is shown because of an invalid invocation, e.g. an unknown flag
+// or a missing subcommand, results in a UsageError and thus ExitUsage.
+func ExitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	var ec ExitCoder
+	if errors.As(err, &ec) {
+		return ec.ExitCode()
+	}
+	return ExitFailure
+}
+
+// WithExitCode wraps an error so that ExitCode returns the given code for it.
+func WithExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCodeError{err: err, code: code}
+}
+
+type exitCodeError struct {
+	err  error
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+func (e *exitCodeError) ExitCode() int {
+	return e.code
+}
+
+// UsageError marks an error as a misuse of the command, as opposed to an
+// operational failure. Exec functions can wrap errors in it to have the
+// command's usage shown along with the error, see WithUsageOnError:
+//
+//	return &cli.UsageError{Err: errors.New("--start must be before --end")}
+type UsageError struct {
+	Err error
+}
+
+// UsageErrorf formats an error according to a format specifier and wraps
+// it in a UsageError.
+func UsageErrorf(format string, args ...any) error {
+	return &UsageError{Err: fmt.Errorf(format, args...)}
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UsageError) ExitCode() int {
+	return ExitUsage
+}
+
+// MissingSubcommandError is returned by Parse if a command that requires a
+// subcommand is invoked without one.
+type MissingSubcommandError struct {
+	// Command is the name of the invoked command.
+	Command string
+	// Available are the names of the command's subcommands.
+	Available []string
+
+	messages Messages
+}
+
+func (e *MissingSubcommandError) Error() string {
+	m := e.messages.withDefaults()
+	if len(e.Available) == 0 {
+		return m.MissingCommand
+	}
+	return m.MissingCommand + ", " + fmt.Sprintf(m.AvailableCommands, strings.Join(e.Available, ", "))
+}
+
+func (e *MissingSubcommandError) ExitCode() int {
+	return ExitUsage
+}
+
+// UnknownCommandError is returned by Parse if a command that requires a
+// subcommand is invoked with a name that matches none of its subcommands.
+type UnknownCommandError struct {
+	// Name is the unmatched command name.
+	Name string
+	// Suggestions are the names of similar subcommands.
+	Suggestions []string
+
+	messages Messages
+}
+
+func (e *UnknownCommandError) Error() string {
+	m := e.messages.withDefaults()
+	msg := fmt.Sprintf(m.UnknownCommand, fmt.Sprintf("%q", e.Name))
+	if len(e.Suggestions) == 0 {
+		return msg
+	}
+
+	quoted := make([]string, 0, len(e.Suggestions))
+	for _, s := range e.Suggestions {
+		quoted = append(quoted, fmt.Sprintf("%q", s))
+	}
+	return fmt.Sprintf("%s (%s)", msg, fmt.Sprintf(m.DidYouMean, strings.Join(quoted, " "+m.Or+" ")))
+}
+
+func (e *UnknownCommandError) ExitCode() int {
+	return ExitUsage
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"errors"
-	"flag"
 	"fmt"
 	"os"
 
@@ -13,7 +12,7 @@ import (
 func main() {
 	if err := execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
 
@@ -24,7 +23,7 @@ func execute() error {
 	opts := []cli.ParseOption{}
 
 	if err := cmd.Parse(args, opts...); err != nil {
-		if errors.Is(err, flag.ErrHelp) {
+		if errors.Is(err, cli.ErrShowHelp) {
 			return nil
 		} else {
 			return fmt.Errorf("error parsing arguments: %w", err)
@@ -36,16 +35,10 @@ func execute() error {
 
 func configure() *cli.Command {
 	root := &cli.Command{
-		Name:       "hello",
-		ShortHelp:  "Say hello to the world.",
-		ShortUsage: "hello <name>",
+		Name:           "hello",
+		ShortHelp:      "Say hello to the world.",
+		PositionalArgs: []string{"name"},
 		Exec: func(ctx context.Context, args []string) error {
-			if len(args) < 1 {
-				return errors.New("not enough arguments")
-			} else if len(args) > 1 {
-				return errors.New("too many arguments")
-			}
-
 			_, err := fmt.Printf("Hello, %s.\n", args[0])
 			return err
 		},
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"errors"
-	"flag"
 	"fmt"
 	"os"
 
@@ -15,7 +14,7 @@ const version string = "v0.0.0+unknown"
 func main() {
 	if err := execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
 
@@ -26,7 +25,7 @@ func execute() error {
 	opts := []cli.ParseOption{}
 
 	if err := cmd.Parse(args, opts...); err != nil {
-		if errors.Is(err, flag.ErrHelp) {
+		if errors.Is(err, cli.ErrShowHelp) {
 			return nil
 		} else {
 			return fmt.Errorf("error parsing arguments: %w", err)
@@ -40,23 +39,23 @@ func configure() *cli.Command {
 	out := os.Stdout
 
 	root := &cli.Command{
-		Name:       "version",
-		ShortUsage: "version <command>",
-		Exec: func(ctx context.Context, args []string) error {
-			return flag.ErrHelp
-		},
+		Name:              "version",
+		ShortUsage:        "version <command>",
+		RequireSubcommand: true,
 	}
 
 	// default version info taken from BuildInfo
-	defaultCmd := cli.DefaultVersionCommand(out)
-	defaultCmd.Name = "default"
-	defaultCmd.ShortHelp = "Show default version information"
+	defaultCmd := cli.DefaultVersionCommand(out,
+		cli.WithName("default"),
+		cli.WithShortHelp("Show default version information"),
+	)
 
 	// custom version info based on BuildInfo but explicit version number
 	customInfo := cli.NewBuildInfo(version)
-	customCmd := cli.NewVersionCommand(customInfo, out)
-	customCmd.Name = "custom"
-	customCmd.ShortHelp = "Show custom version information"
+	customCmd := cli.NewVersionCommand(customInfo, out,
+		cli.WithName("custom"),
+		cli.WithShortHelp("Show custom version information"),
+	)
 
 	root.Subcommands = []*cli.Command{
 		defaultCmd,
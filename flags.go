@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// NegatableBool defines a bool flag with the specified name, default value,
+// and usage string, together with a `no-<name>` flag that sets it to false.
+// If both are given on the command line, the last one wins.
+func NegatableBool(fs *flag.FlagSet, name string, value bool, usage string) *bool {
+	p := new(bool)
+	NegatableBoolVar(fs, p, name, value, usage)
+	return p
+}
+
+// NegatableBoolVar is like NegatableBool but stores the value in p.
+func NegatableBoolVar(fs *flag.FlagSet, p *bool, name string, value bool, usage string) {
+	fs.BoolVar(p, name, value, usage)
+	fs.Var((*negatedBoolValue)(p), "no-"+name, fmt.Sprintf("negate --%s", name))
+}
+
+// negatedBoolValue is a bool flag value that stores the negation of what it
+// is set to.
+type negatedBoolValue bool
+
+func (b *negatedBoolValue) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*b = negatedBoolValue(!v)
+	return nil
+}
+
+func (b *negatedBoolValue) Get() any {
+	return !bool(*b)
+}
+
+func (b *negatedBoolValue) String() string {
+	if b == nil {
+		return "false"
+	}
+	return strconv.FormatBool(!bool(*b))
+}
+
+func (b *negatedBoolValue) IsBoolFlag() bool {
+	return true
+}
+
+// OptionalString is a string flag value whose value is optional. If the flag
+// is given without a value, e.g. `--color`, it is set to a predefined value,
+// otherwise to the given one, e.g. `--color=never`.
+//
+// Like for boolean flags, a value must be attached with `=`. Since the flag
+// package passes "true" for a flag without value, `--color=true` is treated
+// like `--color`.
+type OptionalString struct {
+	p       *string
+	present string
+}
+
+// NewOptionalString returns an optional string value storing into p, which
+// is set to value initially and to present if the flag is given without a
+// value.
+func NewOptionalString(p *string, value string, present string) *OptionalString {
+	*p = value
+	return &OptionalString{p: p, present: present}
+}
+
+// OptionalStringVar defines an optional string flag with the specified name,
+// default value, value if present without one, and usage string.
+func OptionalStringVar(fs *flag.FlagSet, p *string, name string, value string, present string, usage string) {
+	fs.Var(NewOptionalString(p, value, present), name, usage)
+}
+
+func (o *OptionalString) Set(s string) error {
+	if s == "true" {
+		s = o.present
+	}
+	*o.p = s
+	return nil
+}
+
+func (o *OptionalString) Get() any {
+	return *o.p
+}
+
+func (o *OptionalString) String() string {
+	if o == nil || o.p == nil {
+		return ""
+	}
+	return *o.p
+}
+
+func (o *OptionalString) IsBoolFlag() bool {
+	return true
+}
+
+// EnumValue is a string flag value restricted to a set of allowed values.
+// Shell completion offers the allowed values, see WithCompletion.
+type EnumValue struct {
+	p       *string
+	allowed []string
+}
+
+// NewEnumValue returns an enum value storing into p, which is set to value
+// initially.
+func NewEnumValue(p *string, value string, allowed ...string) *EnumValue {
+	*p = value
+	return &EnumValue{p: p, allowed: allowed}
+}
+
+// EnumVar defines an enum flag with the specified name, default value,
+// allowed values, and usage string.
+func EnumVar(fs *flag.FlagSet, p *string, name string, value string, allowed []string, usage string) {
+	fs.Var(NewEnumValue(p, value, allowed...), name, usage)
+}
+
+// Allowed returns the allowed values.
+func (e *EnumValue) Allowed() []string {
+	return slices.Clone(e.allowed)
+}
+
+func (e *EnumValue) Set(s string) error {
+	if !slices.Contains(e.allowed, s) {
+		return fmt.Errorf("must be one of %s", strings.Join(e.allowed, ", "))
+	}
+	*e.p = s
+	return nil
+}
+
+func (e *EnumValue) Get() any {
+	return *e.p
+}
+
+func (e *EnumValue) String() string {
+	if e == nil || e.p == nil {
+		return ""
+	}
+	return *e.p
+}
+
+// ExpandAllFlag makes the bool flag named group act as a shorthand for all
+// the member bool flags: setting it to true also sets each member to true,
+// so they are reported as set, e.g. by fs.Visit. This is how `--all`-style
+// flags can be built that select everything in a group.
+//
+// It returns an error if any of the flags is not defined or not a bool flag.
+func ExpandAllFlag(fs *flag.FlagSet, group string, members ...string) error {
+	f := fs.Lookup(group)
+	if f == nil || !isBoolFlag(f) {
+		return fmt.Errorf("expand all flag: no bool flag -%s", group)
+	}
+	for _, name := range members {
+		if m := fs.Lookup(name); m == nil || !isBoolFlag(m) {
+			return fmt.Errorf("expand all flag: no bool flag -%s", name)
+		}
+	}
+
+	f.Value = &expandAllValue{Value: f.Value, fs: fs, members: members}
+	return nil
+}
+
+// expandAllValue wraps a bool flag value to set the member flags along with
+// it.
+type expandAllValue struct {
+	flag.Value
+	fs      *flag.FlagSet
+	members []string
+}
+
+func (v *expandAllValue) Set(s string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	if b, _ := strconv.ParseBool(s); !b {
+		return nil
+	}
+	for _, name := range v.members {
+		if err := v.fs.Set(name, "true"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *expandAllValue) Get() any {
+	if g, ok := v.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return v.Value.String()
+}
+
+func (v *expandAllValue) IsBoolFlag() bool {
+	return true
+}
+
+// FlagProvider registers a reusable group of flags, e.g. for authentication
+// or output options shared by many commands.
+type FlagProvider interface {
+	RegisterFlags(fs *flag.FlagSet)
+}
+
+// AddFlags registers the flags of the providers on the command's flag set,
+// creating it if necessary. Unlike the flag package, which panics, it returns
+// an error if a flag name is defined more than once, in which case no flags
+// are added.
+func (cmd *Command) AddFlags(providers ...FlagProvider) error {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	}
+
+	var added []*flag.Flag
+	seen := map[string]bool{}
+	for _, p := range providers {
+		fs, err := providerFlags(cmd.Name, p)
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.Name, err)
+		}
+
+		var dup error
+		fs.VisitAll(func(f *flag.Flag) {
+			if dup == nil && (seen[f.Name] || cmd.Flags.Lookup(f.Name) != nil) {
+				dup = fmt.Errorf("%s: flag redefined: %s", cmd.Name, f.Name)
+			}
+			seen[f.Name] = true
+			added = append(added, f)
+		})
+		if dup != nil {
+			return dup
+		}
+	}
+
+	for _, f := range added {
+		cmd.Flags.Var(f.Value, f.Name, f.Usage)
+		cmd.Flags.Lookup(f.Name).DefValue = f.DefValue
+	}
+	return nil
+}
+
+// providerFlags returns a flag set with the flags of p, turning the panic of
+// a flag defined twice by p into an error. Any other panic is propagated.
+func providerFlags(name string, p FlagProvider) (fs *flag.FlagSet, err error) {
+	fs = flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	defer func() {
+		if r := recover(); r != nil {
+			msg, ok := r.(string)
+			if !ok || !strings.Contains(msg, "flag redefined: ") {
+				panic(r)
+			}
+			err = fmt.Errorf("register flags: %s", msg)
+		}
+	}()
+	p.RegisterFlags(fs)
+	return fs, nil
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ExitFunc terminates the process with the given exit code. It is used by
+// Main and by WithInterruptHandling and defaults to os.Exit. Tests can replace
+// it to capture the exit code instead of terminating the test binary.
+var ExitFunc = os.Exit
+
+// Main parses args, runs the command, reports any error using HandleError
+// and exits with the resulting code using ExitFunc, which is called even on
+// success. It is meant to be the entire body of a program's main function:
+//
+//	cmd.Main(context.Background(), os.Args[1:])
+func (cmd *Command) Main(ctx context.Context, args []string, options ...ParseOption) {
+	err := cmd.Parse(args, options...)
+	if err == nil {
+		err = cmd.Run(ctx)
+	}
+	ExitFunc(cmd.HandleError(err))
+}
+
+// HandleError reports an error returned by Parse or Run on the command's
+// standard error and returns the corresponding exit code, see ExitCode.
+// Nothing is reported for nil errors, requested help or errors wrapping
+// ErrSilent.
+//
+// The amount of detail depends on the verbosity set with WithVerbosity: at
+// level 0 only the message of the root cause is shown, at level 1 the full
+// error message, and at level 2 and above the entire error chain. See
+// WithJSONErrors for machine-readable output.
+func (cmd *Command) HandleError(err error) int {
+	code := ExitCode(err)
+	if err == nil || errors.Is(err, flag.ErrHelp) || errors.Is(err, ErrSilent) {
+		return code
+	}
+
+	msg := formatError(err, cmd.opts.verbosity)
+	if cmd.opts.jsonErrors {
+		_ = json.NewEncoder(cmd.stderr()).Encode(jsonError{Error: msg, Code: code})
+		return code
+	}
+
+	prefix := "Error: "
+	if cmd.opts.errorPrefix != nil {
+		prefix = *cmd.opts.errorPrefix
+	}
+	fmt.Fprintf(cmd.stderr(), "%s%s\n", prefix, msg)
+	return code
+}
+
+// jsonError is the representation of an error reported with WithJSONErrors.
+type jsonError struct {
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+}
+
+func formatError(err error, verbosity int) string {
+	switch {
+	case verbosity <= 0:
+		return rootCause(err).Error()
+	case verbosity == 1:
+		return err.Error()
+	}
+
+	if _, ok := err.(fmt.Formatter); ok {
+		return fmt.Sprintf("%+v", err)
+	}
+
+	lines := []string{err.Error()}
+	for e := errors.Unwrap(err); e != nil; e = errors.Unwrap(e) {
+		lines = append(lines, "  caused by: "+e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rootCause returns the innermost error of a chain of wrapped errors. Errors
+// wrapping multiple errors are not unwrapped, nor are ArgError and UsageError,
+// which describe the context of their underlying error themselves.
+func rootCause(err error) error {
+	for {
+		switch err.(type) {
+		case *ArgError, *UsageError:
+			return err
+		}
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+)
+
+// OpenInput opens the named file for reading, or returns standard input if
+// name is `-`. Closing the returned reader does not close standard input.
+func OpenInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// InputArg returns the single input argument in args, which is either a file
+// name or `-` for standard input. It returns a UsageError unless there is
+// exactly one argument.
+func InputArg(args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		return "", UsageErrorf("missing input, provide a file name or - for standard input")
+	case 1:
+		return args[0], nil
+	default:
+		return "", UsageErrorf("too many inputs, got %d", len(args))
+	}
+}
+
+// ReadLinesArg reads items from in, one per line, e.g. for commands that
+// take `-` as their sole argument to process items from standard input.
+// Surrounding whitespace is trimmed and empty lines are skipped. Reading is
+// abandoned when ctx is done, in which case the context's error is returned.
+func ReadLinesArg(ctx context.Context, in io.Reader) ([]string, error) {
+	type result struct {
+		lines []string
+		err   error
+	}
+	ch := make(chan result, 1)
+
+	go func() {
+		var lines []string
+		s := bufio.NewScanner(in)
+		for s.Scan() {
+			if ctx.Err() != nil {
+				return
+			}
+			if line := strings.TrimSpace(s.Text()); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		ch <- result{lines, s.Err()}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.lines, r.err
+	}
+}
@@ -0,0 +1,78 @@
+package cli
+
+// Messages holds the strings the package shows to users on its own, e.g. to
+// translate them. Empty fields fall back to those of DefaultMessages. Help
+// texts provided by commands, like ShortHelp and LongHelp, are not affected.
+type Messages struct {
+	// Section headings of the help output.
+	Usage         string
+	Commands      string
+	Options       string
+	GlobalOptions string
+	Examples      string
+
+	// UnknownCommand is the format of an unknown command error, given the
+	// quoted command name.
+	UnknownCommand string
+	// DidYouMean is the format of the suggestions appended to an unknown
+	// command error, given the quoted suggestions joined by Or.
+	DidYouMean string
+	// Or joins suggestions.
+	Or string
+	// MissingCommand is the message of a missing subcommand error.
+	MissingCommand string
+	// AvailableCommands is the format of the list appended to a missing
+	// subcommand error, given the comma-separated command names.
+	AvailableCommands string
+}
+
+// DefaultMessages are the messages used unless others are set with
+// WithMessages.
+var DefaultMessages = Messages{
+	Usage:             "USAGE",
+	Commands:          "COMMANDS",
+	Options:           "OPTIONS",
+	GlobalOptions:     "GLOBAL OPTIONS",
+	Examples:          "EXAMPLES",
+	UnknownCommand:    "unknown command %s",
+	DidYouMean:        "did you mean %s?",
+	Or:                "or",
+	MissingCommand:    "missing command",
+	AvailableCommands: "available commands: %s",
+}
+
+// WithMessages replaces the messages shown by the package, see Messages.
+func WithMessages(m Messages) ParseOption {
+	return func(po *ParseOptions) error {
+		po.messages = m.withDefaults()
+		return nil
+	}
+}
+
+// withDefaults returns a copy of m with empty fields set to the default
+// messages.
+func (m Messages) withDefaults() Messages {
+	d := DefaultMessages
+	for _, f := range []struct{ v, def *string }{
+		{&m.Usage, &d.Usage},
+		{&m.Commands, &d.Commands},
+		{&m.Options, &d.Options},
+		{&m.GlobalOptions, &d.GlobalOptions},
+		{&m.Examples, &d.Examples},
+		{&m.UnknownCommand, &d.UnknownCommand},
+		{&m.DidYouMean, &d.DidYouMean},
+		{&m.Or, &d.Or},
+		{&m.MissingCommand, &d.MissingCommand},
+		{&m.AvailableCommands, &d.AvailableCommands},
+	} {
+		if *f.v == "" {
+			*f.v = *f.def
+		}
+	}
+	return m
+}
+
+// messages returns the messages of the command.
+func (c *Command) messages() Messages {
+	return c.opts.messages.withDefaults()
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// MultiCall selects the root command of a multi-call binary, which behaves
+// as different programs depending on the name it is invoked by, e.g. through
+// symlinks. The commands are keyed by program name.
+//
+// If the base name of os.Args[0] is one of the keys, that command is
+// returned. Otherwise a command of that name is returned that has all the
+// commands as subcommands, so each program can be invoked by name as in
+// `mybinary foo ...`. A key that differs from the command's name is added to
+// its aliases. Either way, the returned command is parsed with the
+// arguments after the program name:
+//
+//	cmd := cli.MultiCall(map[string]*cli.Command{"foo": foo, "bar": bar})
+//	cmd.Main(context.Background(), os.Args[1:])
+func MultiCall(commands map[string]*Command) *Command {
+	return multiCall(os.Args[0], commands)
+}
+
+func multiCall(argv0 string, commands map[string]*Command) *Command {
+	name := strings.TrimSuffix(filepath.Base(argv0), ".exe")
+	if cmd, ok := commands[name]; ok {
+		return cmd
+	}
+
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+
+	root := &Command{
+		Name:              name,
+		RequireSubcommand: true,
+	}
+	for _, n := range names {
+		cmd := commands[n]
+		if cmd.Name != n && !slices.Contains(cmd.Aliases, n) {
+			cmd.Aliases = append(cmd.Aliases, n)
+		}
+		root.Subcommands = append(root.Subcommands, cmd)
+	}
+	return root
+}
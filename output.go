@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// OutputEncoder writes a value to w in a specific output format.
+type OutputEncoder interface {
+	Encode(w io.Writer, v any) error
+}
+
+// OutputEncoderFunc is an adapter to allow the use of ordinary functions as
+// output encoders.
+type OutputEncoderFunc func(w io.Writer, v any) error
+
+func (f OutputEncoderFunc) Encode(w io.Writer, v any) error {
+	return f(w, v)
+}
+
+var outputEncoders = struct {
+	sync.RWMutex
+	m map[string]OutputEncoder
+}{
+	m: map[string]OutputEncoder{
+		"json": OutputEncoderFunc(encodeJSON),
+		"yaml": OutputEncoderFunc(encodeYAML),
+		"text": OutputEncoderFunc(encodeText),
+	},
+}
+
+// RegisterOutputEncoder makes an output encoder available under the given
+// format name, replacing any encoder previously registered under it.
+func RegisterOutputEncoder(name string, enc OutputEncoder) {
+	outputEncoders.Lock()
+	defer outputEncoders.Unlock()
+
+	outputEncoders.m[name] = enc
+}
+
+// LookupOutputEncoder returns the output encoder registered under the given
+// format name.
+func LookupOutputEncoder(name string) (OutputEncoder, bool) {
+	outputEncoders.RLock()
+	defer outputEncoders.RUnlock()
+
+	enc, ok := outputEncoders.m[name]
+	return enc, ok
+}
+
+// OutputFormats returns the sorted names of all registered output encoders.
+func OutputFormats() []string {
+	outputEncoders.RLock()
+	defer outputEncoders.RUnlock()
+
+	names := make([]string, 0, len(outputEncoders.m))
+	for name := range outputEncoders.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeText(w io.Writer, v any) error {
+	_, err := fmt.Fprintln(w, v)
+	return err
+}
+
+// encodeYAML writes v as YAML, using its JSON representation to determine
+// field names and order.
+func encodeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeOrdered(dec)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, strings.Join(yamlLines(node), "\n")+"\n")
+	return err
+}
+
+type orderedField struct {
+	key   string
+	value any
+}
+
+type orderedObject []orderedField
+
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := orderedObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, orderedField{key: key.(string), value: value})
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err = dec.Token()
+		return arr, err
+	default:
+		return tok, nil
+	}
+}
+
+func yamlLines(node any) []string {
+	var lines []string
+
+	switch n := node.(type) {
+	case orderedObject:
+		if len(n) == 0 {
+			return []string{"{}"}
+		}
+		for _, field := range n {
+			key := yamlScalar(field.key)
+			if isYAMLCollection(field.value) {
+				lines = append(lines, key+":")
+				for _, line := range yamlLines(field.value) {
+					lines = append(lines, "  "+line)
+				}
+			} else {
+				lines = append(lines, key+": "+yamlLines(field.value)[0])
+			}
+		}
+	case []any:
+		if len(n) == 0 {
+			return []string{"[]"}
+		}
+		for _, item := range n {
+			itemLines := yamlLines(item)
+			lines = append(lines, "- "+itemLines[0])
+			for _, line := range itemLines[1:] {
+				lines = append(lines, "  "+line)
+			}
+		}
+	default:
+		lines = append(lines, yamlScalar(n))
+	}
+
+	return lines
+}
+
+func isYAMLCollection(node any) bool {
+	switch n := node.(type) {
+	case orderedObject:
+		return len(n) > 0
+	case []any:
+		return len(n) > 0
+	}
+	return false
+}
+
+var (
+	yamlPlainRegexp    = regexp.MustCompile(`^[A-Za-z0-9_./+-][A-Za-z0-9_ ./+()-]*$`)
+	yamlReservedRegexp = regexp.MustCompile(`^(?i:true|false|yes|no|on|off|null|~|[-+]?[0-9][0-9_.eE+-]*)$`)
+)
+
+func yamlScalar(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		if s != strings.TrimSpace(s) || !yamlPlainRegexp.MatchString(s) || yamlReservedRegexp.MatchString(s) {
+			b, _ := json.Marshal(s)
+			return string(b)
+		}
+		return s
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+// JSONStreamWriter writes values as newline-delimited JSON, one compact JSON
+// document per line, so that commands can stream many records without
+// buffering them. It is safe for concurrent use.
+type JSONStreamWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// NewJSONStreamWriter returns a writer of newline-delimited JSON to w.
+func NewJSONStreamWriter(w io.Writer) *JSONStreamWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONStreamWriter{w: w, enc: enc}
+}
+
+// Encode writes v as a single line of JSON. If the underlying writer has a
+// `Flush() error` method, e.g. a bufio.Writer, it is flushed afterwards so
+// the record is not held back.
+func (s *JSONStreamWriter) Encode(v any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.enc.Encode(v); err != nil {
+		return fmt.Errorf("encode json record: %w", err)
+	}
+	if f, ok := s.w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return fmt.Errorf("flush json record: %w", err)
+		}
+	}
+	return nil
+}
@@ -5,16 +5,55 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 )
 
 type ParseOptions struct {
 	envVarEnabled bool
 	envVarPrefix  string
+
+	caseInsensitiveCommands bool
+	dryRunEnabled           bool
+	helpAllEnabled          bool
+	sortedCommands          bool
+	usageOnError            bool
+	autoHelpDisabled        bool
+	helpSections            []HelpSection
+	verbosity               int
+	normalizeFlag           func(name string) string
+	interruptHandling       bool
+	forceExitCode           int
+	configDumpEnabled       bool
+	assumeYesEnabled        bool
+	jsonErrors              bool
+	completionEnabled       bool
+	messages                Messages
+	phaseTraceEnabled       bool
+	listCommandsEnabled     bool
+	getenv                  func(key string) string
+	startupVersion          VersionInfo
+	errorPrefix             *string
+	debugInvocationEnabled  bool
+	commandMatcher          func(candidate string, arg string) bool
 }
 
 type ParseOption func(*ParseOptions) error
 
+// EnvVar declares an environment-only input of a command. The name of the
+// environment variable is derived from Name and the prefix configured with
+// WithEnvVarPrefix, the same way as for flags.
+type EnvVar struct {
+	Name  string
+	Usage string
+
+	// Required makes parsing fail if the variable is unset or empty.
+	Required bool
+	// Validate, if set, is called with the value of the variable if it is
+	// set.
+	Validate func(value string) error
+}
+
 func WithEnvVars() ParseOption {
 	return func(po *ParseOptions) error {
 		po.envVarEnabled = true
@@ -30,61 +69,603 @@ func WithEnvVarPrefix(prefix string) ParseOption {
 	}
 }
 
+// WithEnv makes environment variables be looked up in env instead of the
+// process environment, e.g. for hermetic tests. It applies to flags bound
+// with WithEnvVars and to the command's EnvVars.
+func WithEnv(env map[string]string) ParseOption {
+	return func(po *ParseOptions) error {
+		po.getenv = func(key string) string {
+			return env[key]
+		}
+		return nil
+	}
+}
+
+// WithCaseInsensitiveCommands makes subcommand names and aliases match
+// regardless of case.
+func WithCaseInsensitiveCommands() ParseOption {
+	return func(po *ParseOptions) error {
+		po.caseInsensitiveCommands = true
+		return nil
+	}
+}
+
+// WithCommandMatcher sets the function deciding whether an argument selects
+// a subcommand, given the subcommand's name or one of its aliases as the
+// candidate. It takes precedence over WithCaseInsensitiveCommands.
+func WithCommandMatcher(match func(candidate string, arg string) bool) ParseOption {
+	return func(po *ParseOptions) error {
+		po.commandMatcher = match
+		return nil
+	}
+}
+
+// WithConfigDump adds a `--config-dump` flag to every command. If it is set,
+// running the command prints the value and source of each flag of the
+// selected command instead of executing it.
+func WithConfigDump() ParseOption {
+	return func(po *ParseOptions) error {
+		po.configDumpEnabled = true
+		return nil
+	}
+}
+
+// WithDebugInvocation adds a `--debug-invocation` flag to every command. If
+// it is set, running the command prints the path of the selected command,
+// the values and sources of the flags of each command on the way and the
+// remaining arguments instead of executing it.
+func WithDebugInvocation() ParseOption {
+	return func(po *ParseOptions) error {
+		po.debugInvocationEnabled = true
+		return nil
+	}
+}
+
+// WithDryRun adds a `--dry-run` flag to every command. If it is set on a
+// command, IsDryRun reports true for it and all its subcommands.
+func WithDryRun() ParseOption {
+	return func(po *ParseOptions) error {
+		po.dryRunEnabled = true
+		return nil
+	}
+}
+
+// WithAssumeYes adds `--yes` and `-y` flags to every command. If either is set
+// on a command, Confirm answers yes without prompting for it and all its
+// subcommands.
+func WithAssumeYes() ParseOption {
+	return func(po *ParseOptions) error {
+		po.assumeYesEnabled = true
+		return nil
+	}
+}
+
+// WithCompletion enables shell completion. Running the root command with the
+// hidden `__complete` command prints the candidates for the last of the
+// remaining arguments, which is how the scripts generated by GenCompletion
+// query them.
+func WithCompletion() ParseOption {
+	return func(po *ParseOptions) error {
+		po.completionEnabled = true
+		return nil
+	}
+}
+
+// WithErrorPrefix sets the prefix of errors reported by HandleError, e.g.
+// "myapp: error: ", instead of the default "Error: ". The prefix is written
+// as is, so it may contain terminal escape sequences to colorize it.
+func WithErrorPrefix(prefix string) ParseOption {
+	return func(po *ParseOptions) error {
+		po.errorPrefix = &prefix
+		return nil
+	}
+}
+
+// WithJSONErrors makes HandleError report errors as a JSON object with the
+// error message and exit code, e.g. `{"error":"...","code":1}`, so they can
+// be parsed by programs wrapping the command.
+func WithJSONErrors() ParseOption {
+	return func(po *ParseOptions) error {
+		po.jsonErrors = true
+		return nil
+	}
+}
+
+// WithPhaseTrace adds a `--trace` flag to every command. If it is set, the
+// time spent in the PersistentPreRun hooks, the Exec function and the
+// PersistentPostRun hooks is printed to standard error once the command
+// returns, e.g. `pre=2ms exec=140ms post=1ms`.
+func WithPhaseTrace() ParseOption {
+	return func(po *ParseOptions) error {
+		po.phaseTraceEnabled = true
+		return nil
+	}
+}
+
+// WithListCommands adds a `--list-commands` flag to the root command that
+// prints the paths of all subcommands to standard output, one per line,
+// e.g. `remote add`. Hidden and disabled commands are omitted. Like help,
+// it makes Parse return ErrShowHelp.
+func WithListCommands() ParseOption {
+	return func(po *ParseOptions) error {
+		po.listCommandsEnabled = true
+		return nil
+	}
+}
+
+// WithStartupVersionLog makes Run log the version information at info level
+// using the default slog logger before running the command, so that logs
+// can be correlated with builds.
+func WithStartupVersionLog(info VersionInfo) ParseOption {
+	return func(po *ParseOptions) error {
+		po.startupVersion = info
+		return nil
+	}
+}
+
+// WithHelpAll adds a `--help-all` flag to the root command that prints the
+// help of the entire command tree.
+func WithHelpAll() ParseOption {
+	return func(po *ParseOptions) error {
+		po.helpAllEnabled = true
+		return nil
+	}
+}
+
+// WithSortedCommands lists subcommands in help output in alphabetical order
+// instead of the order they are declared in.
+func WithSortedCommands() ParseOption {
+	return func(po *ParseOptions) error {
+		po.sortedCommands = true
+		return nil
+	}
+}
+
+// WithUsageOnError shows the command's usage when its Exec function returns
+// a UsageError.
+func WithUsageOnError() ParseOption {
+	return func(po *ParseOptions) error {
+		po.usageOnError = true
+		return nil
+	}
+}
+
+// WithoutAutoHelp stops `-h` and `--help` from showing the command's help.
+// Unless the command defines flags with these names, they are treated like
+// any other undefined flag.
+func WithoutAutoHelp() ParseOption {
+	return func(po *ParseOptions) error {
+		po.autoHelpDisabled = true
+		return nil
+	}
+}
+
+// WithHelpSections sets the order of the sections in help output. Sections
+// that are omitted are not shown, empty sections are always skipped.
+func WithHelpSections(order ...HelpSection) ParseOption {
+	return func(po *ParseOptions) error {
+		po.helpSections = order
+		return nil
+	}
+}
+
+// WithVerbosity sets the level of detail with which HandleError reports
+// errors.
+func WithVerbosity(level int) ParseOption {
+	return func(po *ParseOptions) error {
+		po.verbosity = level
+		return nil
+	}
+}
+
+// WithFlagNormalization rewrites the names of flags given on the command line
+// before they are resolved, e.g. to keep accepting a flag's previous name.
+// The function receives flag names without leading dashes, so shorthand
+// flags like `-o` are passed as `o` just like long ones.
+func WithFlagNormalization(normalize func(name string) string) ParseOption {
+	return func(po *ParseOptions) error {
+		po.normalizeFlag = normalize
+		return nil
+	}
+}
+
 func (cmd *Command) Parse(args []string, options ...ParseOption) error {
 	if cmd.Name == "" {
 		return errors.New("name is required")
 	}
-	if cmd.Flags == nil {
-		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+
+	var opts ParseOptions
+	for _, option := range options {
+		if err := option(&opts); err != nil {
+			return fmt.Errorf("%s: %w", cmd.Name, err)
+		}
+	}
+	cmd.setup(opts)
+	cmd.warnings = nil
+	cmd.helpRequested = false
+	cmd.unknownFlags = nil
+
+	if cmd.DisableFlagParsing {
+		// mark flags as parsed without consuming any arguments
+		if err := cmd.Flags.Parse(nil); err != nil {
+			return fmt.Errorf("%s: %w", cmd.Name, err)
+		}
+		cmd.args = args
+		cmd.selected = cmd
+		cmd.warnDeprecated()
+
+		tracef("%s: selected with raw args %q", cmd.Name, cmd.args)
+		return nil
+	}
+
+	cmd.completion = nil
+	if opts.completionEnabled && cmd.parent == nil && len(args) > 0 && args[0] == completeCommandName {
+		if err := cmd.Flags.Parse(nil); err != nil {
+			return fmt.Errorf("%s: %w", cmd.Name, err)
+		}
+		cmd.completion = args[1:]
+		cmd.selected = cmd
+		return nil
 	}
 
+	cmd.helpJSON = helpJSONRequested(args)
 	cmd.Flags.Usage = func() {
-		fmt.Fprintln(cmd.Flags.Output(), DefaultUsage(cmd))
+		cmd.printHelp(cmd.Flags.Output())
 	}
 
-	if err := parse(cmd.Flags, args, options...); err != nil {
+	tracef("%s: parsing %q", cmd.Name, args)
+
+	if err := cmd.parseFlags(args); err != nil {
+		cmd.helpRequested = errors.Is(err, flag.ErrHelp)
 		return fmt.Errorf("%s: %w", cmd.Name, err)
 	}
 
 	cmd.args = cmd.Flags.Args()
 
+	if opts.helpAllEnabled && cmd.parent == nil && testFlag(cmd.Flags, "help-all") {
+		fmt.Fprint(cmd.stdout(), helpAll(cmd))
+		cmd.helpRequested = true
+		return fmt.Errorf("%s: %w", cmd.Name, flag.ErrHelp)
+	}
+
+	if opts.listCommandsEnabled && cmd.parent == nil && testFlag(cmd.Flags, "list-commands") {
+		fmt.Fprint(cmd.stdout(), listCommands(cmd))
+		cmd.helpRequested = true
+		return fmt.Errorf("%s: %w", cmd.Name, flag.ErrHelp)
+	}
+
+	if err := cmd.parseEnvVars(); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Name, err)
+	}
+
+	cmd.Flags.Visit(func(f *flag.Flag) {
+		tracef("%s: flag %s=%q", cmd.Name, f.Name, f.Value.String())
+	})
+
 	// check for subcommands
 	if len(cmd.args) > 0 {
-		for _, subcmd := range cmd.Subcommands {
-			if strings.EqualFold(args[0], subcmd.Name) {
-				cmd.selected = subcmd
-				subcmd.parent = cmd
+		if matches := cmd.matchingSubcommands(cmd.args[0]); len(matches) > 0 {
+			subcmd := matches[0]
+			if len(matches) > 1 {
+				cmd.warnf("command %q is ambiguous, using %q", cmd.args[0], subcmd.Name)
+			}
+
+			cmd.selected = subcmd
+			subcmd.parent = cmd
 
-				return subcmd.Parse(cmd.args[1:], options...)
+			tracef("%s: matched subcommand %q", cmd.Name, subcmd.Name)
+
+			err := subcmd.Parse(cmd.args[1:], options...)
+			cmd.helpRequested = subcmd.helpRequested
+			if err == nil && len(cmd.unknownFlags) > 0 {
+				leaf := subcmd.resolved()
+				leaf.unknownFlags = append(slices.Clone(cmd.unknownFlags), leaf.unknownFlags...)
+				leaf.args = append(slices.Clone(cmd.unknownFlags), leaf.args...)
 			}
+			return err
+		}
+	}
+
+	positional := cmd.args
+	cmd.args = append(slices.Clone(cmd.unknownFlags), positional...)
+
+	if cmd.handlesUnknownCommand() {
+		cmd.selected = cmd
+		cmd.warnDeprecated()
+		tracef("%s: unknown command %q passed to handler", cmd.Name, positional[0])
+		return nil
+	}
+
+	if cmd.RequireSubcommand {
+		if len(positional) > 0 {
+			return fmt.Errorf("%s: %w", cmd.Name, cmd.unknownCommandError(positional[0]))
 		}
+		cmd.Flags.Usage()
+		return fmt.Errorf("%s: %w", cmd.Name, cmd.missingSubcommandError())
 	}
 
 	// select self if no subcommand was found
 	cmd.selected = cmd
+	cmd.warnDeprecated()
+
+	tracef("%s: selected with args %q", cmd.Name, cmd.args)
+
+	if err := cmd.validatePositionalArgs(); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Name, err)
+	}
 
 	return nil
 }
 
-func parse(fs *flag.FlagSet, args []string, options ...ParseOption) error {
-	var opts ParseOptions
-	for _, option := range options {
-		if err := option(&opts); err != nil {
-			return err
+// setup applies the parse options to the command and adds the flags it
+// accepts besides its own, i.e. the persistent flags of its parents and those
+// enabled by the options.
+func (cmd *Command) setup(opts ParseOptions) {
+	cmd.opts = opts
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	}
+	cmd.Flags.SetOutput(cmd.stderr())
+	cmd.addPersistentFlags()
+
+	if cmd.DisableFlagParsing {
+		return
+	}
+
+	if opts.dryRunEnabled && cmd.Flags.Lookup("dry-run") == nil {
+		cmd.Flags.Bool("dry-run", false, "show what would be done without doing it")
+	}
+	if opts.assumeYesEnabled && cmd.Flags.Lookup("yes") == nil {
+		yes := cmd.Flags.Bool("yes", false, "automatically answer yes to confirmation prompts")
+		if cmd.Flags.Lookup("y") == nil {
+			cmd.Flags.BoolVar(yes, "y", false, "shorthand for --yes")
+		}
+	}
+	if opts.phaseTraceEnabled && cmd.Flags.Lookup("trace") == nil {
+		cmd.Flags.Bool("trace", false, "print the duration of the execution phases to standard error")
+	}
+	if opts.debugInvocationEnabled && cmd.Flags.Lookup("debug-invocation") == nil {
+		cmd.Flags.Bool("debug-invocation", false, "print the parsed command, flags and arguments and exit")
+	}
+	if opts.configDumpEnabled && cmd.Flags.Lookup("config-dump") == nil {
+		cmd.Flags.Bool("config-dump", false, "print the effective value and source of each flag and exit")
+	}
+	if opts.helpAllEnabled && cmd.parent == nil && cmd.Flags.Lookup("help-all") == nil {
+		cmd.Flags.Bool("help-all", false, "show help for all commands")
+	}
+	if opts.listCommandsEnabled && cmd.parent == nil && cmd.Flags.Lookup("list-commands") == nil {
+		cmd.Flags.Bool("list-commands", false, "list the paths of all commands")
+	}
+}
+
+func (cmd *Command) parseEnvVars() error {
+	cmd.envValues = map[string]string{}
+	for _, env := range cmd.EnvVars {
+		key := getEnvVarKey(env.Name, cmd.opts.envVarPrefix)
+
+		val := cmd.getenv(key)
+		if val == "" {
+			if env.Required {
+				return UsageErrorf("missing required environment variable %s", key)
+			}
+			continue
+		}
+
+		if env.Validate != nil {
+			if err := env.Validate(val); err != nil {
+				return UsageErrorf("invalid value for environment variable %s: %w", key, err)
+			}
+		}
+		cmd.envValues[env.Name] = val
+	}
+	return nil
+}
+
+// EnvValue returns the value of the environment-only input with the given
+// name, see EnvVar.
+func (cmd *Command) EnvValue(name string) (string, bool) {
+	val, ok := cmd.envValues[name]
+	return val, ok
+}
+
+func (cmd *Command) validatePositionalArgs() error {
+	if len(cmd.PositionalArgs) == 0 {
+		return nil
+	}
+
+	args := cmd.positionalArgs()
+	if len(args) < len(cmd.PositionalArgs) {
+		return UsageErrorf("missing required argument: %s", cmd.PositionalArgs[len(args)])
+	} else if len(args) > len(cmd.PositionalArgs) {
+		return &UsageError{Err: errors.New("too many arguments")}
+	}
+
+	return nil
+}
+
+func (cmd *Command) lookupSubcommand(name string) *Command {
+	if matches := cmd.matchingSubcommands(name); len(matches) > 0 {
+		return matches[0]
+	}
+	return nil
+}
+
+func (cmd *Command) matchingSubcommands(name string) []*Command {
+	var matches []*Command
+	for _, subcmd := range cmd.Subcommands {
+		if !subcmd.enabled() {
+			continue
+		}
+		if cmd.matchCommand(subcmd.Name, name) {
+			matches = append(matches, subcmd)
+			continue
+		}
+		for _, alias := range subcmd.Aliases {
+			if cmd.matchCommand(alias, name) {
+				matches = append(matches, subcmd)
+				break
+			}
 		}
 	}
+	return matches
+}
 
-	provided := map[string]bool{}
+func (cmd *Command) missingSubcommandError() error {
+	err := &MissingSubcommandError{Command: cmd.Name, messages: cmd.messages()}
+	for _, subcmd := range cmd.helpSubcommands() {
+		err.Available = append(err.Available, subcmd.Name)
+	}
+	return err
+}
+
+func (cmd *Command) unknownCommandError(name string) error {
+	return &UnknownCommandError{
+		Name:        name,
+		Suggestions: cmd.suggestSubcommands(name),
+		messages:    cmd.messages(),
+	}
+}
+
+func (cmd *Command) suggestSubcommands(name string) []string {
+	const maxDistance = 2
+
+	var suggestions []string
+	for _, subcmd := range cmd.helpSubcommands() {
+		candidates := append([]string{subcmd.Name}, subcmd.Aliases...)
+		for _, candidate := range candidates {
+			a, b := strings.ToLower(candidate), strings.ToLower(name)
+			if levenshtein(a, b) <= maxDistance || strings.HasPrefix(a, b) {
+				suggestions = append(suggestions, subcmd.Name)
+				break
+			}
+		}
+	}
+	return suggestions
+}
+
+func levenshtein(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(t)]
+}
+
+func (cmd *Command) matchCommand(candidate string, name string) bool {
+	if cmd.opts.commandMatcher != nil {
+		return cmd.opts.commandMatcher(candidate, name)
+	}
+	if cmd.opts.caseInsensitiveCommands {
+		return strings.EqualFold(candidate, name)
+	}
+	return candidate == name
+}
+
+// HelpRequested reports whether parsing the command or one of its
+// subcommands stopped because help was requested.
+func (cmd *Command) HelpRequested() bool {
+	return cmd.helpRequested
+}
+
+// Warnings returns the warnings collected while parsing the command and its
+// selected subcommands.
+//
+// Warnings are reported for environment variables that are ignored because
+// the corresponding flag was set on the command line, for command names that
+// match more than one subcommand and for deprecated commands.
+func (cmd *Command) Warnings() []string {
+	var warnings []string
+	for c := cmd; c != nil; c = c.selected {
+		warnings = append(warnings, c.warnings...)
+		if c.selected == c {
+			break
+		}
+	}
+	return warnings
+}
+
+// warnDeprecated warns if the command or one of its parents is deprecated,
+// using the message of the nearest one.
+func (cmd *Command) warnDeprecated() {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Deprecated == "" {
+			continue
+		}
+		cmd.warnf("command %q is deprecated: %s", c.Name, c.Deprecated)
+		return
+	}
+}
+
+func (cmd *Command) warnf(format string, args ...any) {
+	cmd.warnings = append(cmd.warnings, fmt.Sprintf("%s: %s", cmd.Name, fmt.Sprintf(format, args...)))
+}
+
+func (cmd *Command) parseFlags(args []string) error {
+	fs := cmd.Flags
+	opts := cmd.opts
+
+	// inherited persistent flags keep the source they got from the parent,
+	// which has already considered the environment
+	cmd.sources = map[string]FlagSource{}
+	if cmd.parent != nil {
+		for name := range cmd.inheritedFlags {
+			if src := cmd.parent.FlagSource(name); src != SourceDefault {
+				cmd.sources[name] = src
+			}
+		}
+	}
+	own := map[string]bool{}
 
 	// command-line flags first
 	{
-		if err := fs.Parse(args); err != nil {
+		// help is printed below, depending on whether it was requested
+		usage := fs.Usage
+		fs.Usage = func() {}
+		defer func() { fs.Usage = usage }()
+
+		if opts.normalizeFlag != nil {
+			args = normalizeFlagArgs(fs, args, opts.normalizeFlag)
+		}
+
+		cmd.unknownFlags = nil
+		if cmd.IgnoreUnknownFlags {
+			args, cmd.unknownFlags = splitUnknownFlags(fs, args)
+		}
+
+		err := fs.Parse(args)
+		if errors.Is(err, flag.ErrHelp) && opts.autoHelpDisabled {
+			err = fmt.Errorf("flag provided but not defined: -%s", helpFlagName(args))
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			cmd.printHelp(cmd.stdout())
 			return fmt.Errorf("parse args: %w", err)
+		} else if err != nil {
+			if !opts.autoHelpDisabled {
+				usage()
+			}
+			return &UsageError{Err: fmt.Errorf("parse args: %w", err)}
 		}
 
 		// mark set flags as provided
 		fs.Visit(func(f *flag.Flag) {
-			provided[f.Name] = true
+			cmd.sources[f.Name] = SourceFlag
+			own[f.Name] = true
 		})
 	}
 
@@ -92,33 +673,156 @@ func parse(fs *flag.FlagSet, args []string, options ...ParseOption) error {
 	if opts.envVarEnabled {
 		var visitErr error
 		fs.VisitAll(func(f *flag.Flag) {
-			// skip flags already provided
-			if provided[f.Name] {
-				return
-			}
-
 			key := getEnvVarKey(f.Name, opts.envVarPrefix)
 
-			val := os.Getenv(key)
+			val := cmd.getenv(key)
 			if val == "" {
 				return
 			}
 
+			// skip flags already provided
+			if own[f.Name] {
+				cmd.warnf("environment variable %s ignored, flag %q set on command line", key, f.Name)
+				return
+			}
+			if cmd.sources[f.Name] != SourceDefault {
+				return
+			}
+
 			if err := fs.Set(f.Name, val); err != nil {
 				visitErr = err
+				return
 			}
+			cmd.sources[f.Name] = SourceEnv
 		})
 		if visitErr != nil {
 			return fmt.Errorf("parse env: %w", visitErr)
 		}
+	}
 
-		// mark set flags as provided
-		fs.Visit(func(f *flag.Flag) {
-			provided[f.Name] = true
+	return nil
+}
+
+// normalizeFlagArgs returns a copy of args with the names of all flags the
+// flag set would parse rewritten by normalize.
+func normalizeFlagArgs(fs *flag.FlagSet, args []string, normalize func(string) string) []string {
+	out := slices.Clone(args)
+	for i := 0; i < len(out); i++ {
+		arg := out[i]
+		if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+			break
+		}
+
+		dashes := "-"
+		if arg[1] == '-' {
+			dashes = "--"
+		}
+		name, value, hasValue := strings.Cut(arg[len(dashes):], "=")
+
+		name = normalize(name)
+		if hasValue {
+			out[i] = dashes + name + "=" + value
+		} else {
+			out[i] = dashes + name
+		}
+
+		// skip the value of non-boolean flags
+		if f := fs.Lookup(name); f != nil && !hasValue && !isBoolFlag(f) {
+			i++
+		}
+	}
+	return out
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	bf, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+// addPersistentFlags adds the persistent flags of the command and its parents
+// to its flag set, unless it already defines flags of the same names.
+func (cmd *Command) addPersistentFlags() {
+	for c := cmd; c != nil; c = c.parent {
+		visitAll(c.PersistentFlags, func(f *flag.Flag) {
+			if cmd.Flags.Lookup(f.Name) != nil {
+				return
+			}
+			cmd.Flags.Var(f.Value, f.Name, f.Usage)
+			// the value may already be set by parsing a parent
+			cmd.Flags.Lookup(f.Name).DefValue = f.DefValue
+			if c != cmd {
+				if cmd.inheritedFlags == nil {
+					cmd.inheritedFlags = map[string]bool{}
+				}
+				cmd.inheritedFlags[f.Name] = true
+			}
 		})
 	}
+}
 
-	return nil
+// helpJSONRequested reports whether args contain a `--json` flag, which
+// selects JSON help output.
+func helpJSONRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--json" || arg == "-json" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitUnknownFlags separates the flags in args that are not defined in fs
+// from the others. Like the flag package, it stops looking for flags at the
+// first non-flag argument or `--`. Unknown flags are assumed to have no
+// value unless it is attached with `=`.
+func splitUnknownFlags(fs *flag.FlagSet, args []string) (known []string, unknown []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || arg == "-" || !strings.HasPrefix(arg, "-") {
+			return append(known, args[i:]...), unknown
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil && name != "h" && name != "help" {
+			unknown = append(unknown, arg)
+			continue
+		}
+
+		known = append(known, arg)
+		if f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			known = append(known, args[i])
+		}
+	}
+	return known, unknown
+}
+
+// helpFlagName returns the name of the first help flag in args.
+func helpFlagName(args []string) string {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		name, _, _ = strings.Cut(name, "=")
+		if name == "h" || name == "help" {
+			return name
+		}
+	}
+	return "help"
+}
+
+// getenv returns the value of the environment variable named by key, see
+// WithEnv.
+func (cmd *Command) getenv(key string) string {
+	if cmd.opts.getenv != nil {
+		return cmd.opts.getenv(key)
+	}
+	return os.Getenv(key)
 }
 
 func getEnvVarKey(name string, prefix string) string {
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"slices"
+	"testing"
+)
+
+func TestParseDeprecatedNested(t *testing.T) {
+	noop := func(context.Context, []string) error { return nil }
+
+	tests := []struct {
+		name  string
+		child string
+		want  string
+	}{
+		{
+			name: "inherited",
+			want: `grandchild: command "parent" is deprecated: use other instead`,
+		},
+		{
+			name:  "own message",
+			child: "use sibling instead",
+			want:  `grandchild: command "child" is deprecated: use sibling instead`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grandchild := &Command{Name: "grandchild", Exec: noop}
+			child := &Command{
+				Name:        "child",
+				Deprecated:  tt.child,
+				Subcommands: []*Command{grandchild},
+			}
+			parent := &Command{
+				Name:        "parent",
+				Deprecated:  "use other instead",
+				Subcommands: []*Command{child},
+			}
+
+			if err := parent.Parse([]string{"child", "grandchild"}); err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			got := parent.Warnings()
+			if want := []string{tt.want}; !slices.Equal(got, want) {
+				t.Errorf("Warnings() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParsePersistentFlagSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		wantValue  string
+		wantSource FlagSource
+	}{
+		{
+			name:       "default",
+			args:       []string{"sub", "leaf"},
+			wantValue:  "none",
+			wantSource: SourceDefault,
+		},
+		{
+			name:       "flag on parent",
+			args:       []string{"--config", "cli", "sub", "leaf"},
+			wantValue:  "cli",
+			wantSource: SourceFlag,
+		},
+		{
+			name:       "flag on parent wins over env",
+			args:       []string{"--config", "cli", "sub", "leaf"},
+			env:        map[string]string{"APP_CONFIG": "fromenv"},
+			wantValue:  "cli",
+			wantSource: SourceFlag,
+		},
+		{
+			name:       "flag on leaf wins over env",
+			args:       []string{"sub", "leaf", "--config", "cli"},
+			env:        map[string]string{"APP_CONFIG": "fromenv"},
+			wantValue:  "cli",
+			wantSource: SourceFlag,
+		},
+		{
+			name:       "env",
+			args:       []string{"sub", "leaf"},
+			env:        map[string]string{"APP_CONFIG": "fromenv"},
+			wantValue:  "fromenv",
+			wantSource: SourceEnv,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaf := &Command{
+				Name: "leaf",
+				Exec: func(context.Context, []string) error { return nil },
+			}
+			sub := &Command{Name: "sub", Subcommands: []*Command{leaf}}
+
+			pf := flag.NewFlagSet("app", flag.ContinueOnError)
+			config := pf.String("config", "none", "config file")
+			app := &Command{
+				Name:            "app",
+				PersistentFlags: pf,
+				Subcommands:     []*Command{sub},
+			}
+
+			err := app.Parse(tt.args, WithEnvVarPrefix("APP"), WithEnv(tt.env))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			if *config != tt.wantValue {
+				t.Errorf("config = %q, want %q", *config, tt.wantValue)
+			}
+			if got := leaf.FlagSource("config"); got != tt.wantSource {
+				t.Errorf("FlagSource(config) = %s, want %s", got, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestParseIgnoreUnknownFlags(t *testing.T) {
+	type call struct {
+		cmd  string
+		name string
+		args []string
+	}
+
+	tests := []struct {
+		name    string
+		handler bool
+		args    []string
+		want    call
+	}{
+		{
+			name: "self",
+			args: []string{"--foo=1", "bob"},
+			want: call{cmd: "root", args: []string{"--foo=1", "bob"}},
+		},
+		{
+			name: "subcommand",
+			args: []string{"--foo", "sub", "x"},
+			want: call{cmd: "sub", args: []string{"--foo", "x"}},
+		},
+		{
+			name:    "handler",
+			handler: true,
+			args:    []string{"--foo", "plug", "z"},
+			want:    call{cmd: "handler", name: "plug", args: []string{"--foo", "z"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got call
+			exec := func(name string) ExecFunc {
+				return func(_ context.Context, args []string) error {
+					got = call{cmd: name, args: args}
+					return nil
+				}
+			}
+
+			root := &Command{
+				Name:               "root",
+				IgnoreUnknownFlags: true,
+				PositionalArgs:     []string{"name"},
+				Exec:               exec("root"),
+				Subcommands: []*Command{
+					{Name: "sub", PositionalArgs: []string{"arg"}, Exec: exec("sub")},
+				},
+			}
+			if tt.handler {
+				root.UnknownCommandHandler = func(_ context.Context, name string, args []string) error {
+					got = call{cmd: "handler", name: name, args: args}
+					return nil
+				}
+			}
+
+			if err := root.Parse(tt.args); err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if err := root.Run(context.Background()); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			if got.cmd != tt.want.cmd || got.name != tt.want.name || !slices.Equal(got.args, tt.want.args) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+const (
+	spinnerInterval  = 100 * time.Millisecond
+	progressInterval = time.Second
+)
+
+// Progress reports the status of a long-running operation. On a terminal it
+// shows an animated spinner next to the current status; otherwise it writes
+// the status as plain lines, at most once per second and only when it
+// changed.
+type Progress struct {
+	w   io.Writer
+	tty bool
+
+	mu     sync.Mutex
+	status string
+	dirty  bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewProgress starts reporting progress to w, typically Stdout(ctx). Progress
+// is reported until Stop is called or ctx is done.
+func NewProgress(ctx context.Context, w io.Writer) *Progress {
+	p := &Progress{
+		w:    w,
+		tty:  IsTerminal(w),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go p.run(ctx)
+	return p
+}
+
+// Update sets the current status.
+func (p *Progress) Update(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = fmt.Sprintf(format, args...)
+	p.dirty = true
+}
+
+// Stop stops reporting progress. On a terminal the status line is cleared,
+// otherwise any pending status is written. It is safe to call Stop more than
+// once.
+func (p *Progress) Stop() {
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+}
+
+func (p *Progress) run(ctx context.Context) {
+	defer close(p.done)
+
+	interval := progressInterval
+	if p.tty {
+		interval = spinnerInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		select {
+		case <-ctx.Done():
+			p.finish()
+			return
+		case <-p.stop:
+			p.finish()
+			return
+		case <-ticker.C:
+			p.render(frame)
+		}
+	}
+}
+
+func (p *Progress) render(frame int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.tty {
+		fmt.Fprintf(p.w, "\r\033[K%s %s", spinnerFrames[frame%len(spinnerFrames)], p.status)
+		return
+	}
+	if p.dirty {
+		fmt.Fprintln(p.w, p.status)
+		p.dirty = false
+	}
+}
+
+func (p *Progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.tty {
+		fmt.Fprint(p.w, "\r\033[K")
+		return
+	}
+	if p.dirty {
+		fmt.Fprintln(p.w, p.status)
+		p.dirty = false
+	}
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"strconv"
+	"time"
+)
+
+type jsonSchema struct {
+	Schema      string                        `json:"$schema"`
+	Title       string                        `json:"title,omitempty"`
+	Description string                        `json:"description,omitempty"`
+	Type        string                        `json:"type"`
+	Properties  map[string]jsonSchemaProperty `json:"properties"`
+}
+
+type jsonSchemaProperty struct {
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+	Default     any    `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// FlagsJSONSchema returns a JSON Schema describing the command's flags.
+//
+// Boolean, string, integer, floating point and duration flags map to the
+// corresponding schema types, durations being strings with format
+// `duration`. Flags of any other type map to strings.
+func (c *Command) FlagsJSONSchema() ([]byte, error) {
+	schema := jsonSchema{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		Title:       c.Name,
+		Description: c.ShortHelp,
+		Type:        "object",
+		Properties:  map[string]jsonSchemaProperty{},
+	}
+
+	if c.Flags != nil {
+		c.Flags.VisitAll(func(f *flag.Flag) {
+			schema.Properties[f.Name] = flagSchemaProperty(f)
+		})
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func flagSchemaProperty(f *flag.Flag) jsonSchemaProperty {
+	_, usage := flag.UnquoteUsage(f)
+
+	prop := jsonSchemaProperty{
+		Type:        "string",
+		Default:     f.DefValue,
+		Description: usage,
+	}
+
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return prop
+	}
+
+	switch getter.Get().(type) {
+	case bool:
+		prop.Type = "boolean"
+		if v, err := strconv.ParseBool(f.DefValue); err == nil {
+			prop.Default = v
+		}
+	case int, int64, uint, uint64:
+		prop.Type = "integer"
+		if v, err := strconv.ParseInt(f.DefValue, 0, 64); err == nil {
+			prop.Default = v
+		} else if v, err := strconv.ParseUint(f.DefValue, 0, 64); err == nil {
+			prop.Default = v
+		}
+	case float64:
+		prop.Type = "number"
+		if v, err := strconv.ParseFloat(f.DefValue, 64); err == nil {
+			prop.Default = v
+		}
+	case time.Duration:
+		prop.Format = "duration"
+	}
+
+	return prop
+}
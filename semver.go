@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type semver struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+// parseSemver parses a semantic version with an optional `v` prefix. Missing
+// minor and patch numbers default to zero and build metadata is ignored.
+func parseSemver(s string) (semver, error) {
+	var v semver
+
+	str := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	str, _, _ = strings.Cut(str, "+")
+	str, pre, hasPre := strings.Cut(str, "-")
+	if hasPre {
+		if pre == "" {
+			return v, fmt.Errorf("invalid version: %q", s)
+		}
+		v.prerelease = strings.Split(pre, ".")
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version: %q", s)
+	}
+	nums := []*int{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version: %q", s)
+		}
+		*nums[i] = n
+	}
+
+	return v, nil
+}
+
+// compare returns -1, 0 or +1 depending on whether v is lower than, equal
+// to or greater than w, following semantic versioning precedence.
+func (v semver) compare(w semver) int {
+	for _, d := range []int{v.major - w.major, v.minor - w.minor, v.patch - w.patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	switch {
+	case len(v.prerelease) == 0 && len(w.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(w.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.prerelease) && i < len(w.prerelease); i++ {
+		a, b := v.prerelease[i], w.prerelease[i]
+		an, aErr := strconv.Atoi(a)
+		bn, bErr := strconv.Atoi(b)
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(a, b); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return sign(len(v.prerelease) - len(w.prerelease))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+var constraintOperators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+// CheckVersionConstraint reports whether the current version satisfies the
+// constraint. A constraint is a conjunction of comparisons separated by
+// commas or whitespace, e.g. `>=1.2.0 <2.0.0`. The supported operators are
+// `=`, `!=`, `>`, `>=`, `<` and `<=`; a version without operator must match
+// exactly.
+func CheckVersionConstraint(current string, constraint string) (bool, error) {
+	cur, err := parseSemver(current)
+	if err != nil {
+		return false, err
+	}
+
+	terms := strings.Fields(strings.ReplaceAll(constraint, ",", " "))
+	if len(terms) == 0 {
+		return false, fmt.Errorf("empty version constraint")
+	}
+
+	satisfied := true
+	for i := 0; i < len(terms); i++ {
+		term := terms[i]
+
+		op := "="
+		for _, o := range constraintOperators {
+			if strings.HasPrefix(term, o) {
+				op, term = o, strings.TrimPrefix(term, o)
+				break
+			}
+		}
+		// allow whitespace between operator and version
+		if term == "" {
+			if i+1 == len(terms) {
+				return false, fmt.Errorf("invalid version constraint: %q", constraint)
+			}
+			i++
+			term = terms[i]
+		}
+
+		v, err := parseSemver(term)
+		if err != nil {
+			return false, fmt.Errorf("invalid version constraint: %q: %w", constraint, err)
+		}
+
+		c := cur.compare(v)
+		switch op {
+		case "=", "==":
+			satisfied = satisfied && c == 0
+		case "!=":
+			satisfied = satisfied && c != 0
+		case ">":
+			satisfied = satisfied && c > 0
+		case ">=":
+			satisfied = satisfied && c >= 0
+		case "<":
+			satisfied = satisfied && c < 0
+		case "<=":
+			satisfied = satisfied && c <= 0
+		}
+	}
+
+	return satisfied, nil
+}
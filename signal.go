@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// WithInterruptHandling cancels the context passed to Exec when an interrupt
+// signal is received, letting the command clean up. If a second interrupt is
+// received before the command returns, the process exits immediately with
+// forceExitCode using ExitFunc.
+func WithInterruptHandling(forceExitCode int) ParseOption {
+	return func(po *ParseOptions) error {
+		po.interruptHandling = true
+		po.forceExitCode = forceExitCode
+		return nil
+	}
+}
+
+// handleInterrupts returns a context that is cancelled on the first
+// interrupt signal and a function to stop handling signals.
+func (cmd *Command) handleInterrupts(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+			fmt.Fprintln(cmd.stderr(), "interrupt received, cleaning up (press again to force)")
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case <-sigs:
+			ExitFunc(cmd.opts.forceExitCode)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(done)
+		cancel()
+	}
+}
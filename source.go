@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// FlagSource identifies where the value of a flag came from.
+type FlagSource int
+
+const (
+	// SourceDefault means the flag has its default value.
+	SourceDefault FlagSource = iota
+	// SourceFlag means the flag was set on the command line.
+	SourceFlag
+	// SourceEnv means the flag was set from an environment variable.
+	SourceEnv
+)
+
+func (s FlagSource) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceFlag:
+		return "flag"
+	case SourceEnv:
+		return "env"
+	}
+	return fmt.Sprintf("FlagSource(%d)", int(s))
+}
+
+// FlagSource returns where the value of the named flag came from during
+// parsing. Persistent flags inherited from a parent report the source they
+// got there, unless they were set on the command's own command line.
+func (cmd *Command) FlagSource(name string) FlagSource {
+	return cmd.sources[name]
+}
+
+func (cmd *Command) writeConfigDump(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
+	cmd.writeFlagSources(tw, "")
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Name, err)
+	}
+	return nil
+}
+
+// writeInvocation writes what was parsed for the command and its selected
+// subcommands, see WithDebugInvocation.
+func (cmd *Command) writeInvocation(w io.Writer) error {
+	leaf := cmd.resolved()
+
+	tw := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(tw, "command: %s\n", leaf.Path())
+	for c := cmd; ; c = c.selected {
+		var flags strings.Builder
+		c.writeFlagSources(&flags, "  ")
+		if flags.Len() > 0 {
+			fmt.Fprintf(tw, "flags of %s:\n%s", c.Name, flags.String())
+		}
+		if c == leaf {
+			break
+		}
+	}
+	fmt.Fprintf(tw, "args: %q\n", leaf.Args())
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Name, err)
+	}
+	return nil
+}
+
+// writeFlagSources writes a line with the value and source of each flag,
+// omitting the flags that trigger the output.
+func (cmd *Command) writeFlagSources(w io.Writer, indent string) {
+	visitAll(cmd.Flags, func(f *flag.Flag) {
+		if f.Name == "config-dump" || f.Name == "debug-invocation" {
+			return
+		}
+		fmt.Fprintf(w, "%s%s\t%q\t%s\n", indent, f.Name, f.Value.String(), cmd.FlagSource(f.Name))
+	})
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// RegisterStructFlags defines a flag for each field of the struct pointed to
+// by v that has a `flag` tag, bound to the field. The optional `default` and
+// `usage` tags set the flag's default value and usage string:
+//
+//	type config struct {
+//		Verbose bool          `flag:"verbose" usage:"print more output"`
+//		Timeout time.Duration `flag:"timeout" default:"30s" usage:"request timeout"`
+//	}
+//
+// Supported field types are bool, string, int, int64, uint, uint64, float64,
+// time.Duration and types whose pointer implements flag.Value. Tagged fields
+// of any other type result in an error.
+func RegisterStructFlags(fs *flag.FlagSet, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("register struct flags: expected non-nil pointer to struct")
+	}
+	rv = rv.Elem()
+
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		name, ok := field.Tag.Lookup("flag")
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("register struct flags: field %s: not exported", field.Name)
+		}
+
+		def, hasDefault := field.Tag.Lookup("default")
+		usage := field.Tag.Get("usage")
+
+		if err := registerFieldFlag(fs, rv.Field(i), name, def, hasDefault, usage); err != nil {
+			return fmt.Errorf("register struct flags: field %s: %w", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func registerFieldFlag(fs *flag.FlagSet, fv reflect.Value, name string, def string, hasDefault bool, usage string) error {
+	ptr := fv.Addr().Interface()
+
+	if value, ok := ptr.(flag.Value); ok {
+		if hasDefault {
+			if err := value.Set(def); err != nil {
+				return fmt.Errorf("invalid default %q: %w", def, err)
+			}
+		}
+		fs.Var(value, name, usage)
+		return nil
+	}
+
+	if !hasDefault {
+		def = fmt.Sprint(fv.Interface())
+	}
+
+	invalidDefault := func(err error) error {
+		return fmt.Errorf("invalid default %q: %w", def, err)
+	}
+
+	switch p := ptr.(type) {
+	case *bool:
+		d, err := strconv.ParseBool(def)
+		if err != nil {
+			return invalidDefault(err)
+		}
+		fs.BoolVar(p, name, d, usage)
+	case *string:
+		fs.StringVar(p, name, def, usage)
+	case *int:
+		d, err := strconv.ParseInt(def, 0, strconv.IntSize)
+		if err != nil {
+			return invalidDefault(err)
+		}
+		fs.IntVar(p, name, int(d), usage)
+	case *int64:
+		d, err := strconv.ParseInt(def, 0, 64)
+		if err != nil {
+			return invalidDefault(err)
+		}
+		fs.Int64Var(p, name, d, usage)
+	case *uint:
+		d, err := strconv.ParseUint(def, 0, strconv.IntSize)
+		if err != nil {
+			return invalidDefault(err)
+		}
+		fs.UintVar(p, name, uint(d), usage)
+	case *uint64:
+		d, err := strconv.ParseUint(def, 0, 64)
+		if err != nil {
+			return invalidDefault(err)
+		}
+		fs.Uint64Var(p, name, d, usage)
+	case *float64:
+		d, err := strconv.ParseFloat(def, 64)
+		if err != nil {
+			return invalidDefault(err)
+		}
+		fs.Float64Var(p, name, d, usage)
+	case *time.Duration:
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return invalidDefault(err)
+		}
+		fs.DurationVar(p, name, d, usage)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+
+	return nil
+}
+
+// UnmarshalFlags sets each field of the struct pointed to by v that has a
+// `flag` tag to the value of the flag of that name in fs, typically after
+// parsing. It is the counterpart of RegisterStructFlags for flags that are
+// not bound to the struct.
+//
+// Fields whose pointer implements flag.Value are set from the flag's string
+// representation. Other fields must have the exact type of the flag's value
+// as returned by flag.Getter, e.g. int for flags defined with fs.Int. It is
+// an error if a tagged field has no flag or a mismatching type.
+func UnmarshalFlags(fs *flag.FlagSet, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("unmarshal flags: expected non-nil pointer to struct")
+	}
+	rv = rv.Elem()
+
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		name, ok := field.Tag.Lookup("flag")
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("unmarshal flags: field %s: not exported", field.Name)
+		}
+
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unmarshal flags: field %s: flag provided but not defined: -%s", field.Name, name)
+		}
+		if err := unmarshalFieldFlag(rv.Field(i), f); err != nil {
+			return fmt.Errorf("unmarshal flags: field %s: %w", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalFieldFlag(fv reflect.Value, f *flag.Flag) error {
+	if value, ok := fv.Addr().Interface().(flag.Value); ok {
+		if err := value.Set(f.Value.String()); err != nil {
+			return fmt.Errorf("invalid value %q for flag -%s: %w", f.Value.String(), f.Name, err)
+		}
+		return nil
+	}
+
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return fmt.Errorf("flag -%s does not provide a typed value", f.Name)
+	}
+	gv := reflect.ValueOf(getter.Get())
+	if !gv.IsValid() || !gv.Type().AssignableTo(fv.Type()) {
+		return fmt.Errorf("flag -%s has type %T, field has type %s", f.Name, getter.Get(), fv.Type())
+	}
+	fv.Set(gv)
+	return nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"io"
+	"os"
+)
+
+// IsTerminal reports whether w writes to a terminal. Writers that wrap a file
+// can expose it through an `Unwrap() io.Writer` method. Buffers, pipes and
+// regular files are not terminals.
+func IsTerminal(w io.Writer) bool {
+	for w != nil {
+		switch v := w.(type) {
+		case *os.File:
+			return isCharDevice(v)
+		case interface{ Stat() (os.FileInfo, error) }:
+			info, err := v.Stat()
+			return err == nil && info.Mode()&os.ModeCharDevice != 0
+		case interface{ Unwrap() io.Writer }:
+			w = v.Unwrap()
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func isCharDevice(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
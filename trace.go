@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// traceEnvVar is the environment variable that enables tracing of command
+// resolution to standard error, e.g. `CLI_DEBUG=1`.
+const traceEnvVar = "CLI_DEBUG"
+
+func traceEnabled() bool {
+	v, err := strconv.ParseBool(os.Getenv(traceEnvVar))
+	return err == nil && v
+}
+
+func tracef(format string, args ...any) {
+	if !traceEnabled() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "cli: "+format+"\n", args...)
+}
+
+type phaseTimingsKey struct{}
+
+type phase int
+
+const (
+	phasePre phase = iota
+	phaseExec
+	phasePost
+)
+
+// phaseTimings accumulates the durations of the execution phases traced with
+// WithPhaseTrace.
+type phaseTimings struct {
+	mu        sync.Mutex
+	durations [3]time.Duration
+}
+
+func phaseTimingsFrom(ctx context.Context) *phaseTimings {
+	pt, _ := ctx.Value(phaseTimingsKey{}).(*phaseTimings)
+	return pt
+}
+
+// add adds the time since start to the phase. It is a no-op on a nil
+// receiver, i.e. if tracing is disabled.
+func (pt *phaseTimings) add(p phase, start time.Time) {
+	if pt == nil {
+		return
+	}
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.durations[p] += time.Since(start)
+}
+
+func (pt *phaseTimings) String() string {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return fmt.Sprintf("pre=%s exec=%s post=%s",
+		roundDuration(pt.durations[phasePre]),
+		roundDuration(pt.durations[phaseExec]),
+		roundDuration(pt.durations[phasePost]),
+	)
+}
+
+// roundDuration rounds d to milliseconds, or to microseconds if it is
+// shorter than a millisecond.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
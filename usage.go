@@ -3,10 +3,51 @@ package cli
 import (
 	"flag"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"text/tabwriter"
 )
 
+// HelpSection identifies a section of the help output.
+type HelpSection int
+
+const (
+	SectionUsage HelpSection = iota
+	SectionDescription
+	SectionCommands
+	SectionFlags
+	SectionExamples
+)
+
+var defaultHelpSections = []HelpSection{
+	SectionUsage,
+	SectionDescription,
+	SectionCommands,
+	SectionFlags,
+	SectionExamples,
+}
+
+// HelpString returns the command's rendered help text, as shown when help is
+// requested.
+func (c *Command) HelpString() string {
+	return DefaultUsage(c)
+}
+
+// printHelp writes the command's help to w, as JSON if requested, see
+// HelpJSON. Help that was asked for is printed to standard output, help
+// shown because of an error, e.g. by the flag set's Usage, to standard
+// error.
+func (c *Command) printHelp(w io.Writer) {
+	if c.helpJSON {
+		if b, err := c.HelpJSON(); err == nil {
+			fmt.Fprintln(w, string(b))
+			return
+		}
+	}
+	fmt.Fprintln(w, c.HelpString())
+}
+
 func DefaultUsage(c *Command) string {
 	var b strings.Builder
 
@@ -15,59 +56,145 @@ func DefaultUsage(c *Command) string {
 		fmt.Fprintf(&b, "\n")
 	}
 
-	fmt.Fprintf(&b, "USAGE\n")
+	sections := c.opts.helpSections
+	if sections == nil {
+		sections = defaultHelpSections
+	}
+	for _, section := range sections {
+		switch section {
+		case SectionUsage:
+			writeUsageSection(&b, c)
+		case SectionDescription:
+			writeDescriptionSection(&b, c)
+		case SectionCommands:
+			writeCommandsSection(&b, c)
+		case SectionFlags:
+			writeFlagsSection(&b, c)
+		case SectionExamples:
+			writeExamplesSection(&b, c)
+		}
+	}
+
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func writeUsageSection(b *strings.Builder, c *Command) {
+	fmt.Fprintf(b, "%s\n", c.messages().Usage)
 	if c.ShortUsage != "" {
-		fmt.Fprintf(&b, "  %s\n", c.ShortUsage)
+		fmt.Fprintf(b, "  %s\n", c.ShortUsage)
 	} else {
-		fmt.Fprintf(&b, "  %s\n", DefaultShortUsage(c))
+		fmt.Fprintf(b, "  %s\n", DefaultShortUsage(c))
 	}
-	fmt.Fprintf(&b, "\n")
+	fmt.Fprintf(b, "\n")
+}
 
+func writeDescriptionSection(b *strings.Builder, c *Command) {
 	if c.LongHelp != "" {
-		fmt.Fprintf(&b, "%s\n\n", c.LongHelp)
+		fmt.Fprintf(b, "%s\n\n", c.LongHelp)
 	}
+}
 
-	if len(c.Subcommands) > 0 {
-		fmt.Fprintf(&b, "COMMANDS\n")
-		tw := tabwriter.NewWriter(&b, 0, 2, 2, ' ', 0)
-		for _, subcommand := range c.Subcommands {
-			fmt.Fprintf(tw, "  %s\t%s\n", subcommand.Name, subcommand.ShortHelp)
+func writeCommandsSection(b *strings.Builder, c *Command) {
+	subcommands := c.helpSubcommands()
+	if len(subcommands) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "%s\n", c.messages().Commands)
+	tw := tabwriter.NewWriter(b, 0, 2, 2, ' ', 0)
+	for _, subcommand := range subcommands {
+		fmt.Fprintf(tw, "  %s\t%s\n", subcommand.Name, subcommand.ShortHelp)
+	}
+	tw.Flush()
+	fmt.Fprintf(b, "\n")
+}
+
+func writeFlagsSection(b *strings.Builder, c *Command) {
+	if countFlags(c.Flags) == 0 && len(c.EnvVars) == 0 {
+		return
+	}
+
+	local := func(f *flag.Flag) bool { return !c.inheritedFlags[f.Name] }
+	global := func(f *flag.Flag) bool { return c.inheritedFlags[f.Name] }
+
+	if countFlags(c.Flags) > len(c.inheritedFlags) || len(c.EnvVars) > 0 {
+		fmt.Fprintf(b, "%s\n", c.messages().Options)
+		tw := tabwriter.NewWriter(b, 0, 2, 2, ' ', 0)
+		writeFlagLines(tw, c, local)
+		for _, env := range c.EnvVars {
+			fmt.Fprintf(tw, "  [env: %s]\t%s\n", getEnvVarKey(env.Name, c.opts.envVarPrefix), env.Usage)
 		}
 		tw.Flush()
-		fmt.Fprintf(&b, "\n")
+		fmt.Fprintf(b, "\n")
 	}
 
-	if countFlags(c.Flags) > 0 {
-		fmt.Fprintf(&b, "OPTIONS\n")
-		tw := tabwriter.NewWriter(&b, 0, 2, 2, ' ', 0)
-		c.Flags.VisitAll(func(f *flag.Flag) {
-			_, usage := flag.UnquoteUsage(f)
-
-			if len(f.Name) == 1 {
-				fmt.Fprintf(tw, "  -%s\t%s\n", f.Name, usage)
-			} else {
-				fmt.Fprintf(tw, "  --%s\t%s\n", f.Name, usage)
-			}
-		})
+	if len(c.inheritedFlags) > 0 {
+		fmt.Fprintf(b, "%s\n", c.messages().GlobalOptions)
+		tw := tabwriter.NewWriter(b, 0, 2, 2, ' ', 0)
+		writeFlagLines(tw, c, global)
 		tw.Flush()
-		fmt.Fprintf(&b, "\n")
+		fmt.Fprintf(b, "\n")
 	}
+}
 
-	return strings.TrimSpace(b.String()) + "\n"
+// writeFlagLines writes a help line for each flag of the command for which
+// filter reports true.
+func writeFlagLines(w io.Writer, c *Command, filter func(*flag.Flag) bool) {
+	visitAll(c.Flags, func(f *flag.Flag) {
+		if !filter(f) {
+			return
+		}
+		name, usage := flag.UnquoteUsage(f)
+
+		flagName := "--" + f.Name
+		if len(f.Name) == 1 {
+			flagName = "-" + f.Name
+		}
+		if name != "" {
+			flagName += " " + name
+		}
+
+		if c.opts.envVarEnabled {
+			usage += fmt.Sprintf(" [env: %s]", getEnvVarKey(f.Name, c.opts.envVarPrefix))
+		}
+
+		fmt.Fprintf(w, "  %s\t%s\n", flagName, usage)
+	})
+}
+
+func writeExamplesSection(b *strings.Builder, c *Command) {
+	if c.Examples == "" {
+		return
+	}
+
+	fmt.Fprintf(b, "%s\n", c.messages().Examples)
+	for _, line := range strings.Split(strings.TrimRight(c.Examples, "\n"), "\n") {
+		fmt.Fprintf(b, "  %s\n", line)
+	}
+	fmt.Fprintf(b, "\n")
+}
+
+// helpSubcommands returns the enabled and visible subcommands in the order
+// they are listed in help output, which is the declaration order unless
+// sorting is enabled.
+func (c *Command) helpSubcommands() []*Command {
+	subcommands := slices.DeleteFunc(slices.Clone(c.Subcommands), func(sub *Command) bool {
+		return !sub.enabled() || sub.Hidden
+	})
+	if c.opts.sortedCommands {
+		slices.SortStableFunc(subcommands, func(a, b *Command) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+	return subcommands
 }
 
 func DefaultShortUsage(c *Command) string {
 	builder := strings.Builder{}
 
-	u := c.Name
-	p := c.parent
-	for p != nil {
-		u = fmt.Sprintf("%s %s", p.Name, u)
-		p = p.parent
-	}
-	builder.WriteString(u)
+	builder.WriteString(c.Path())
 
-	if len(c.Subcommands) > 0 {
+	if len(c.helpSubcommands()) > 0 {
 		builder.WriteString(" [command]")
 	}
 
@@ -75,12 +202,76 @@ func DefaultShortUsage(c *Command) string {
 		builder.WriteString(" [option]...")
 	}
 
-	builder.WriteString(" [arg]...")
+	if len(c.PositionalArgs) > 0 {
+		for _, name := range c.PositionalArgs {
+			fmt.Fprintf(&builder, " <%s>", name)
+		}
+	} else {
+		builder.WriteString(" [arg]...")
+	}
 
 	return builder.String()
 }
 
+// helpAll returns the help of root followed by that of each of its visible
+// subcommands, indented by depth and in the order they are listed in help.
+// The subcommands are rendered as if they had been parsed with the options
+// of root.
+func helpAll(root *Command) string {
+	var b strings.Builder
+
+	var write func(c *Command, depth int)
+	write = func(c *Command, depth int) {
+		if c != root {
+			c.setup(root.opts)
+			fmt.Fprintf(&b, "\n")
+		}
+		indent := strings.Repeat("  ", depth)
+		for _, line := range strings.Split(strings.TrimSuffix(c.HelpString(), "\n"), "\n") {
+			if line == "" {
+				fmt.Fprintf(&b, "\n")
+			} else {
+				fmt.Fprintf(&b, "%s%s\n", indent, line)
+			}
+		}
+		for _, sub := range c.helpSubcommands() {
+			sub.parent = c
+			write(sub, depth+1)
+		}
+	}
+	write(root, 0)
+
+	return b.String()
+}
+
+// listCommands returns the paths of all visible subcommands of root below
+// it, one per line, e.g. `remote add`.
+func listCommands(root *Command) string {
+	var b strings.Builder
+
+	_ = root.Walk(func(c *Command) error {
+		if c == root || c.hiddenBelow(root) {
+			return nil
+		}
+		path := c.Name
+		for p := c.parent; p != root; p = p.parent {
+			path = p.Name + " " + path
+		}
+		fmt.Fprintln(&b, path)
+		return nil
+	})
+
+	return b.String()
+}
+
 func countFlags(fs *flag.FlagSet) (n int) {
-	fs.VisitAll(func(*flag.Flag) { n++ })
+	visitAll(fs, func(*flag.Flag) { n++ })
 	return n
 }
+
+// visitAll is like fs.VisitAll but accepts a nil flag set.
+func visitAll(fs *flag.FlagSet, fn func(*flag.Flag)) {
+	if fs != nil {
+		fs.VisitAll(fn)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHelpSubcommandOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ParseOption
+		want []string
+	}{
+		{
+			name: "declaration order",
+			want: []string{"zeta", "alpha", "mid"},
+		},
+		{
+			name: "sorted",
+			opts: []ParseOption{WithSortedCommands()},
+			want: []string{"alpha", "mid", "zeta"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noop := func(context.Context, []string) error { return nil }
+
+			var out bytes.Buffer
+			root := &Command{
+				Name:   "root",
+				Stdout: &out,
+				Subcommands: []*Command{
+					{Name: "zeta", ShortHelp: "last", Exec: noop},
+					{Name: "alpha", ShortHelp: "first", Exec: noop},
+					{Name: "mid", ShortHelp: "middle", Exec: noop},
+				},
+			}
+
+			err := root.Parse([]string{"-h"}, tt.opts...)
+			if !errors.Is(err, flag.ErrHelp) {
+				t.Fatalf("Parse: got %v, want %v", err, flag.ErrHelp)
+			}
+
+			var got []string
+			for _, line := range strings.Split(out.String(), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) == 2 && slices.Contains([]string{"first", "middle", "last"}, fields[1]) {
+					got = append(got, fields[0])
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("subcommands = %q, want %q\n%s", got, tt.want, out.String())
+			}
+		})
+	}
+}
@@ -1,15 +1,22 @@
 package cli
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"os/exec"
+	"runtime"
 	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -30,31 +37,228 @@ type VersionInfo interface {
 	GoVersion() string
 }
 
+// ModifiedState describes whether the source tree had uncommitted local
+// changes, distinguishing a clean tree from missing VCS information.
+type ModifiedState int
+
+const (
+	// No VCS information is available
+	ModifiedUnknown ModifiedState = iota
+	// The source tree had no uncommitted changes
+	ModifiedClean
+	// The source tree had uncommitted changes
+	ModifiedDirty
+)
+
+func (s ModifiedState) String() string {
+	switch s {
+	case ModifiedClean:
+		return "clean"
+	case ModifiedDirty:
+		return "dirty"
+	}
+	return "unknown"
+}
+
+// ModifiedStateOf returns the modified state of the version information. If
+// info does not provide a `ModifiedState() ModifiedState` method like
+// BuildInfo, the state is derived from Modified, and is unknown for a clean
+// tree without revision.
+func ModifiedStateOf(info VersionInfo) ModifiedState {
+	if v, ok := info.(interface{ ModifiedState() ModifiedState }); ok {
+		return v.ModifiedState()
+	}
+	switch {
+	case info.Modified():
+		return ModifiedDirty
+	case info.Revision() != "":
+		return ModifiedClean
+	}
+	return ModifiedUnknown
+}
+
+// VersionSource identifies a source the version number can be taken from.
+type VersionSource int
+
+const (
+	// The version passed explicitly to NewBuildInfo
+	VersionSourceExplicit VersionSource = iota
+	// A pseudo-version derived from the VCS revision and time
+	VersionSourcePseudo
+	// The main module version recorded by the Go toolchain
+	VersionSourceModule
+)
+
+var defaultVersionPrecedence = []VersionSource{
+	VersionSourceExplicit,
+	VersionSourcePseudo,
+	VersionSourceModule,
+}
+
+// VersionData holds a snapshot of version information, e.g. to embed it in
+// other output. Its JSON encoding uses the same field names as the version
+// command.
+type VersionData struct {
+	Version   string `json:"Version"`
+	Revision  string `json:"Revision"`
+	Time      string `json:"Time"`
+	GoVersion string `json:"GoVersion"`
+	Modified  bool   `json:"Modified"`
+}
+
+// VersionSnapshot returns the current values of the version information.
+func VersionSnapshot(info VersionInfo) VersionData {
+	return VersionData{
+		Version:   info.Version(),
+		Revision:  info.Revision(),
+		Time:      info.Time(),
+		GoVersion: info.GoVersion(),
+		Modified:  info.Modified(),
+	}
+}
+
+// logVersion logs the version information using the default slog logger.
+func logVersion(ctx context.Context, info VersionInfo) {
+	d := VersionSnapshot(info)
+	slog.InfoContext(ctx, "version information",
+		slog.String("version", d.Version),
+		slog.String("revision", d.Revision),
+		slog.String("time", d.Time),
+		slog.String("go_version", d.GoVersion),
+		slog.Bool("modified", d.Modified),
+	)
+}
+
 type BuildInfo struct {
-	buildInfo *debug.BuildInfo
-	version   string
+	buildInfo  *debug.BuildInfo
+	version    string
+	precedence []VersionSource
+	noPseudo   bool
+
+	strict     bool
+	strictOut  io.Writer
+	strictOnce sync.Once
+}
+
+type BuildInfoOption func(*BuildInfo)
+
+// WithVersionPrecedence sets the order in which version sources are
+// consulted. Sources that are omitted are never used.
+func WithVersionPrecedence(sources ...VersionSource) BuildInfoOption {
+	return func(bi *BuildInfo) {
+		bi.precedence = slices.Clone(sources)
+	}
+}
+
+// WithoutPseudoVersion disables the pseudo-version fallback, regardless of
+// the order given by WithVersionPrecedence.
+func WithoutPseudoVersion() BuildInfoOption {
+	return func(bi *BuildInfo) {
+		bi.noPseudo = true
+	}
+}
+
+// WithStrictVersion never synthesizes a pseudo-version. Instead, a warning is
+// written to w, or os.Stderr if nil, if no explicit version is set although
+// VCS information is available, e.g. because an `-ldflags` setting is
+// missing for a release build.
+func WithStrictVersion(w io.Writer) BuildInfoOption {
+	return func(bi *BuildInfo) {
+		bi.strict = true
+		bi.strictOut = w
+	}
 }
 
-func NewBuildInfo(version string) *BuildInfo {
+func NewBuildInfo(version string, opts ...BuildInfoOption) *BuildInfo {
 	info, ok := debug.ReadBuildInfo()
 	if !ok {
 		info = &debug.BuildInfo{}
 	}
+	return NewBuildInfoFrom(info, version, opts...)
+}
 
-	return &BuildInfo{
-		buildInfo: info,
-		version:   version,
+// NewBuildInfoFrom is like NewBuildInfo but uses the given build information
+// instead of reading it from the running binary, e.g. to test with synthetic
+// `vcs.revision`, `vcs.time` and `vcs.modified` settings. A nil info is
+// treated as empty.
+func NewBuildInfoFrom(info *debug.BuildInfo, version string, opts ...BuildInfoOption) *BuildInfo {
+	if info == nil {
+		info = &debug.BuildInfo{}
 	}
+
+	bi := &BuildInfo{
+		buildInfo:  info,
+		version:    version,
+		precedence: defaultVersionPrecedence,
+	}
+	for _, opt := range opts {
+		opt(bi)
+	}
+
+	return bi
+}
+
+// NewEmbeddedVersionInfo creates version information with the version number
+// read from the file at path in fsys, e.g. a `VERSION` file embedded with
+// `go:embed`. If the file cannot be read or is empty, the explicit version
+// and build information are used as with NewBuildInfo.
+func NewEmbeddedVersionInfo(version string, fsys fs.FS, path string, opts ...BuildInfoOption) *BuildInfo {
+	if data, err := fs.ReadFile(fsys, path); err == nil {
+		if v := strings.TrimSpace(string(data)); v != "" {
+			version = v
+		}
+	}
+	return NewBuildInfo(version, opts...)
+}
+
+// VersionFromGitDescribe returns the version described by
+// `git describe --tags --always --dirty` for the repository in the current
+// working directory, for use in build scripts or `go generate`.
+func VersionFromGitDescribe() (string, error) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		return "", fmt.Errorf("git describe: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(git, "describe", "--tags", "--always", "--dirty")
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git describe: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("git describe: %w", err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
 }
 
 func (bi *BuildInfo) Version() string {
-	if bi.version != "" {
-		return bi.version
-    } else if v := bi.pseudoVersion(); v != "" {
-        return v
-    }
+	for _, src := range bi.precedence {
+		var v string
+		switch src {
+		case VersionSourceExplicit:
+			v = bi.version
+		case VersionSourcePseudo:
+			if bi.noPseudo {
+				continue
+			}
+			if bi.strict {
+				bi.warnMissingVersion()
+				continue
+			}
+			v = bi.pseudoVersion()
+		case VersionSourceModule:
+			v = bi.buildInfo.Main.Version
+		}
+		if v != "" {
+			return v
+		}
+	}
 
-	return bi.buildInfo.Main.Version
+	return ""
 }
 
 func (bi *BuildInfo) Revision() string {
@@ -76,30 +280,99 @@ func (bi *BuildInfo) Time() string {
 }
 
 func (bi *BuildInfo) Modified() bool {
+	return bi.ModifiedState() == ModifiedDirty
+}
+
+// ModifiedState returns the modified state recorded by the Go toolchain,
+// which is unknown if the binary was built without VCS information.
+func (bi *BuildInfo) ModifiedState() ModifiedState {
 	for _, setting := range bi.buildInfo.Settings {
 		if setting.Key == "vcs.modified" {
 			v, err := strconv.ParseBool(setting.Value)
 			if err != nil {
-				return false
+				return ModifiedUnknown
 			}
-			return v
+			if v {
+				return ModifiedDirty
+			}
+			return ModifiedClean
 		}
 	}
-	return false
+	return ModifiedUnknown
 }
 
 func (bi *BuildInfo) GoVersion() string {
 	return bi.buildInfo.GoVersion
 }
 
+func (bi *BuildInfo) warnMissingVersion() {
+	if bi.version != "" || bi.Revision() == "" {
+		return
+	}
+	bi.strictOnce.Do(func() {
+		w := bi.strictOut
+		if w == nil {
+			w = os.Stderr
+		}
+		fmt.Fprintf(w, "warning: no explicit version set for build of revision %s\n", bi.Revision())
+	})
+}
+
 func (bi *BuildInfo) pseudoVersion() string {
-    t, err := time.Parse(time.RFC3339, bi.Time())
-    if err != nil {
-        return ""
-    }
-    timestamp := t.Format("060102030405")
-    revision := bi.Revision()[:12]
-    return fmt.Sprintf("v0.0.0-%s-%s", timestamp, revision)
+	t, err := time.Parse(time.RFC3339, bi.Time())
+	if err != nil {
+		return ""
+	}
+	revision := bi.Revision()
+	if len(revision) < 12 {
+		return ""
+	}
+	timestamp := t.Format("060102030405")
+	return fmt.Sprintf("v0.0.0-%s-%s", timestamp, revision[:12])
+}
+
+// RequireGoVersion returns an error if the running Go version, as reported by
+// runtime.Version, is lower than min, e.g. "1.21" or "go1.21.4". Development
+// builds of Go are assumed to satisfy any minimum.
+func RequireGoVersion(min string) error {
+	min = strings.TrimPrefix(min, "go")
+	want, err := parseSemver(min)
+	if err != nil {
+		return fmt.Errorf("invalid minimum Go version: %w", err)
+	}
+
+	current := runtime.Version()
+	have, ok := parseGoVersion(current)
+	if !ok {
+		return nil
+	}
+	if have.compare(want) < 0 {
+		return fmt.Errorf("go%s or later is required, running %s", min, current)
+	}
+	return nil
+}
+
+// parseGoVersion parses a Go release version like "go1.21.4" or "go1.22rc1",
+// reporting false for development versions or otherwise unknown formats.
+func parseGoVersion(s string) (semver, bool) {
+	if !strings.HasPrefix(s, "go") {
+		return semver{}, false
+	}
+	s = strings.TrimPrefix(s, "go")
+	// strip suffixes like " X:boringcrypto"
+	s, _, _ = strings.Cut(s, " ")
+	for _, pre := range []string{"rc", "beta", "alpha"} {
+		if i := strings.Index(s, pre); i > 0 {
+			s = s[:i] + "-" + s[i:]
+			break
+		}
+	}
+
+	v, err := parseSemver(s)
+	if err != nil {
+		return semver{}, false
+	}
+	return v, true
 }
 
 func DefaultVersionInfo() VersionInfo {
@@ -109,75 +382,246 @@ func DefaultVersionInfo() VersionInfo {
 	}
 
 	return &BuildInfo{
-		buildInfo: info,
+		buildInfo:  info,
+		precedence: defaultVersionPrecedence,
 	}
 }
 
-func NewVersionCommand(info VersionInfo, out io.Writer) *Command {
-	cfg := versionCmdConfig{
-		version: info,
-		flags:   flag.NewFlagSet("version", flag.ExitOnError),
-		out:     out,
+// VersionCommandOption configures the command created by NewVersionCommand.
+type VersionCommandOption func(*versionCmdConfig)
+
+// WithLatestVersionFunc sets the function used by `--check-update` to fetch
+// the latest available version.
+func WithLatestVersionFunc(latest func(ctx context.Context) (string, error)) VersionCommandOption {
+	return func(c *versionCmdConfig) {
+		c.latest = latest
+	}
+}
+
+// WithName sets the name of the version command, "version" by default.
+func WithName(name string) VersionCommandOption {
+	return func(c *versionCmdConfig) {
+		c.name = name
+	}
+}
+
+// WithShortHelp sets the short help of the version command.
+func WithShortHelp(help string) VersionCommandOption {
+	return func(c *versionCmdConfig) {
+		c.shortHelp = help
+	}
+}
+
+// WithProgramName sets the program name shown by `--banner`. By default, the
+// name of the root command is used.
+func WithProgramName(name string) VersionCommandOption {
+	return func(c *versionCmdConfig) {
+		c.programName = name
+	}
+}
+
+// VersionField identifies a piece of version information shown by the
+// version command.
+type VersionField int
+
+const (
+	FieldVersion VersionField = iota
+	FieldRevision
+	FieldTime
+	FieldModified
+	FieldGoVersion
+)
+
+// WithVersionFields restricts the version command to the given fields. The
+// flags selecting other fields are not registered and `--all` shows only the
+// given fields. By default, or if no fields are given, all fields are
+// enabled.
+func WithVersionFields(fields ...VersionField) VersionCommandOption {
+	return func(c *versionCmdConfig) {
+		c.fields = slices.Clone(fields)
+	}
+}
+
+// VersionSchema is the version of the structure of the version command's
+// encoded output, e.g. JSON. It is incremented whenever the structure
+// changes incompatibly.
+const VersionSchema = 1
+
+// WithSchemaField includes a `_schema` field holding VersionSchema in the
+// encoded output of the version command, so that consumers can detect its
+// structure. It is off by default for compatibility with existing consumers
+// but recommended for new integrations.
+func WithSchemaField() VersionCommandOption {
+	return func(c *versionCmdConfig) {
+		c.schemaField = true
 	}
+}
 
-	if cfg.out == nil {
-		cfg.out = os.Stdout
+func NewVersionCommand(info VersionInfo, out io.Writer, opts ...VersionCommandOption) *Command {
+	cfg := versionCmdConfig{
+		name:      "version",
+		shortHelp: "Show version information",
+		version:   info,
+		out:       out,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
 	}
 
+	cfg.flags = flag.NewFlagSet(cfg.name, flag.ContinueOnError)
 	cfg.RegisterFlags(cfg.flags)
 
-	return &Command{
-		Name:      "version",
-		ShortHelp: "Show version information",
+	cfg.cmd = &Command{
+		Name:      cfg.name,
+		ShortHelp: cfg.shortHelp,
 		Flags:     cfg.flags,
 		Exec:      cfg.Exec,
 	}
+	return cfg.cmd
+}
+
+func DefaultVersionCommand(out io.Writer, opts ...VersionCommandOption) *Command {
+	info := GetDefaultVersionInfo()
+	return NewVersionCommand(info, out, opts...)
+}
+
+var defaultVersionInfo struct {
+	sync.RWMutex
+	info VersionInfo
 }
 
-func DefaultVersionCommand(out io.Writer) *Command {
-	info := DefaultVersionInfo()
-	return NewVersionCommand(info, out)
+// SetDefaultVersionInfo sets the application-wide version information
+// returned by GetDefaultVersionInfo.
+func SetDefaultVersionInfo(info VersionInfo) {
+	defaultVersionInfo.Lock()
+	defer defaultVersionInfo.Unlock()
+
+	defaultVersionInfo.info = info
+}
+
+// GetDefaultVersionInfo returns the version information set with
+// SetDefaultVersionInfo, or DefaultVersionInfo if none was set.
+func GetDefaultVersionInfo() VersionInfo {
+	defaultVersionInfo.RLock()
+	defer defaultVersionInfo.RUnlock()
+
+	if defaultVersionInfo.info != nil {
+		return defaultVersionInfo.info
+	}
+	return DefaultVersionInfo()
 }
 
 type versionCmdConfig struct {
+	name        string
+	shortHelp   string
+	programName string
+	schemaField bool
+
+	// fields are the enabled fields, all if nil
+	fields []VersionField
+
 	version VersionInfo
 
+	// cmd is the version command itself
+	cmd *Command
+
 	flags *flag.FlagSet
 
+	// out overrides the command's standard output if set
 	out io.Writer
+
+	// latest fetches the latest available version
+	latest func(ctx context.Context) (string, error)
 }
 
 func (c *versionCmdConfig) RegisterFlags(fs *flag.FlagSet) {
 	a := fs.Bool("all", false, "print all information")
-	fs.BoolVar(a, "a", false, "shorthand option for `--all`")
-
-	n := fs.Bool("number", false, "print the version number")
-	fs.BoolVar(n, "n", false, "shorthand option for `--number`")
-	r := fs.Bool("revision", false, "print the commit revision identifier")
-	fs.BoolVar(r, "r", false, "shorthand option for `--revision`")
-	t := fs.Bool("time", false, "print the commit revision modification time")
-	fs.BoolVar(t, "t", false, "shorthand option for `--time`")
-	m := fs.Bool("modified", false, "print the commit revision identifier")
-	fs.BoolVar(m, "m", false, "shorthand option for `--modified`")
-	g := fs.Bool("go-version", false, "print the Go toolchain version")
-	fs.BoolVar(g, "g", false, "shorthand option for `--go-version`")
+	fs.BoolVar(a, "a", false, "shorthand option for --all")
 
-	fs.Bool("json", false, "print information in JSON")
+	members := []string{}
+	for _, field := range versionFields {
+		if !c.fieldEnabled(field) {
+			continue
+		}
+		p := fs.Bool(field.flag, false, field.usage)
+		fs.BoolVar(p, field.short, false, fmt.Sprintf("shorthand option for --%s", field.flag))
+		members = append(members, field.flag)
+	}
+	// the flags are registered above, so this cannot fail
+	_ = ExpandAllFlag(fs, "all", members...)
+	_ = ExpandAllFlag(fs, "a", members...)
+
+	fs.String("format", "text", fmt.Sprintf("comma-separated output formats, each one of: %s", strings.Join(OutputFormats(), ", ")))
+	fs.Bool("json", false, "print information in JSON, same as --format=json")
+	fs.Bool("check-update", false, "check whether a newer version is available")
+	fs.Bool("kv", false, "print information as key=value lines")
+	fs.Bool("short", false, "print only the version number, ignoring all other options")
+	fs.Bool("banner", false, "print the program name, version number and revision, ignoring all other options")
+	fs.String("time-format", "", "Go time `layout` used to format the commit time in text output")
+	fs.Bool("no-newline", false, "do not print a trailing newline, in any output format")
+	fs.Bool("verbose", false, "print the modified state as clean, dirty or unknown in text output")
+	fs.Bool("require-complete", false, "fail if any of the version fields is empty, e.g. for builds without VCS information")
 }
 
 func (c *versionCmdConfig) Exec(ctx context.Context, args []string) error {
-	any := false
-	c.flags.Visit(func(f *flag.Flag) {
-		if f.Name != "json" {
-			any = true
+	out := c.out
+	if out == nil {
+		out = Stdout(ctx)
+	}
+
+	if testFlag(c.flags, "require-complete") {
+		if err := c.checkComplete(); err != nil {
+			return err
 		}
-	})
-	all := testFlag(c.flags, "all")
+	}
+	if testFlag(c.flags, "check-update") {
+		return c.checkUpdate(ctx, out)
+	}
+	if testFlag(c.flags, "short") {
+		return c.writeShort(out)
+	}
+	if testFlag(c.flags, "banner") {
+		return c.writeBanner(out)
+	}
+
+	any := false
+	for _, field := range versionFields {
+		any = any || testFlag(c.flags, field.flag)
+	}
 
+	if testFlag(c.flags, "kv") {
+		return c.writeLine(out, c.renderKeyValue(any))
+	}
+
+	format := c.flags.Lookup("format").Value.String()
 	if testFlag(c.flags, "json") {
-		return c.writeJson(any, all)
+		format = "json"
+	}
+
+	// multiple formats are printed in the given order, separated by a line
+	// containing only `---`
+	outputs := []string{}
+	for _, f := range strings.Split(format, ",") {
+		s, err := c.renderFormat(strings.TrimSpace(f), any)
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, s)
 	}
-	return c.writeText(any, all)
+
+	return c.writeLine(out, strings.Join(outputs, "\n---\n"))
+}
+
+func (c *versionCmdConfig) renderFormat(format string, any bool) (string, error) {
+	if format == "text" {
+		return c.renderText(any), nil
+	}
+
+	enc, ok := LookupOutputEncoder(format)
+	if !ok {
+		return "", fmt.Errorf("unsupported output format: %q", format)
+	}
+	return c.renderEncoded(enc, any)
 }
 
 func testFlag(fs *flag.FlagSet, name string) bool {
@@ -194,63 +638,296 @@ func testFlag(fs *flag.FlagSet, name string) bool {
 	return v
 }
 
-func (c *versionCmdConfig) writeText(any bool, all bool) error {
-	builder := strings.Builder{}
-
-	if !any || testFlag(c.flags, "number") || all {
-		builder.WriteString(c.version.Version())
+func (c *versionCmdConfig) writeLine(w io.Writer, s string) error {
+	var err error
+	if testFlag(c.flags, "no-newline") {
+		_, err = fmt.Fprint(w, s)
+	} else {
+		_, err = fmt.Fprintln(w, s)
 	}
-	if testFlag(c.flags, "revision") || all {
-		builder.WriteString(fmt.Sprintf(" %s", c.version.Revision()))
+	if err != nil {
+		return fmt.Errorf("error writing version information: %w", err)
 	}
-	if testFlag(c.flags, "time") || all {
-		builder.WriteString(fmt.Sprintf(" %s", c.version.Time()))
+	return nil
+}
+
+func (c *versionCmdConfig) checkUpdate(ctx context.Context, w io.Writer) error {
+	if c.latest == nil {
+		return errors.New("update checking not configured")
 	}
-	if testFlag(c.flags, "go-version") || all {
-		builder.WriteString(fmt.Sprintf(" %s", c.version.GoVersion()))
+
+	type result struct {
+		version string
+		err     error
 	}
-	if testFlag(c.flags, "modified") || all {
-		if c.version.Modified() {
-			builder.WriteString(" (modified)")
+	ch := make(chan result, 1)
+	go func() {
+		v, err := c.latest(ctx)
+		ch <- result{version: v, err: err}
+	}()
+
+	var latest string
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("error checking for updates: %w", ctx.Err())
+	case r := <-ch:
+		if r.err != nil {
+			return fmt.Errorf("error checking for updates: %w", r.err)
 		}
+		latest = r.version
 	}
 
-	s := builder.String()
-
-	_, err := fmt.Fprintln(c.out, strings.TrimSpace(s))
+	current := c.version.Version()
+	cur, err := parseSemver(current)
 	if err != nil {
-		return fmt.Errorf("error writing version information: %w", err)
+		return fmt.Errorf("error checking for updates: %w", err)
+	}
+	lat, err := parseSemver(latest)
+	if err != nil {
+		return fmt.Errorf("error checking for updates: %w", err)
+	}
+
+	msg := fmt.Sprintf("%s is up to date", current)
+	if lat.compare(cur) > 0 {
+		msg = fmt.Sprintf("update available: %s (current %s)", latest, current)
+	}
+
+	return c.writeLine(w, msg)
+}
+
+func (c *versionCmdConfig) writeShort(w io.Writer) error {
+	return c.writeLine(w, c.version.Version())
+}
+
+// checkComplete returns an error if any of the enabled fields is empty.
+func (c *versionCmdConfig) checkComplete() error {
+	var missing []string
+	for _, field := range versionFields {
+		if c.fieldEnabled(field) && field.value(c.version) == "" {
+			missing = append(missing, field.key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("incomplete version information, missing: %s", strings.Join(missing, ", "))
 	}
 	return nil
 }
 
-func (c *versionCmdConfig) writeJson(any bool, all bool) error {
-	data := map[string]string{}
+// writeBanner writes the program name, version and abbreviated revision,
+// e.g. `myapp v1.2.3 (abc1234)`.
+func (c *versionCmdConfig) writeBanner(w io.Writer) error {
+	name := c.programName
+	if name == "" {
+		root := c.cmd
+		for root.parent != nil {
+			root = root.parent
+		}
+		name = root.Name
+	}
 
-	if !any || testFlag(c.flags, "number") || all {
-		data["Version"] = c.version.Version()
+	banner := fmt.Sprintf("%s %s", name, c.version.Version())
+	if rev := c.version.Revision(); rev != "" {
+		if len(rev) > 7 {
+			rev = rev[:7]
+		}
+		banner += fmt.Sprintf(" (%s)", rev)
 	}
-	if testFlag(c.flags, "revision") || all {
-		data["Revision"] = c.version.Revision()
+	return c.writeLine(w, banner)
+}
+
+func (c *versionCmdConfig) renderText(any bool) string {
+	parts := []string{}
+	for _, field := range versionFields {
+		if !c.selected(field, any) {
+			continue
+		}
+		if s, ok := field.text(c.version, c.flags); ok && s != "" {
+			parts = append(parts, s)
+		}
 	}
-	if testFlag(c.flags, "time") || all {
-		data["Time"] = c.version.Time()
+
+	return strings.Join(parts, " ")
+}
+
+func (c *versionCmdConfig) renderKeyValue(any bool) string {
+	lines := []string{}
+	for _, field := range versionFields {
+		if c.selected(field, any) {
+			lines = append(lines, fmt.Sprintf("%s=%s", field.key, field.value(c.version)))
+		}
 	}
-	if testFlag(c.flags, "go-version") || all {
-		data["GoVersion"] = c.version.GoVersion()
+
+	return strings.Join(lines, "\n")
+}
+
+func (c *versionCmdConfig) renderEncoded(enc OutputEncoder, any bool) (string, error) {
+	data := versionOutput{}
+	if c.schemaField {
+		data.Schema = ptr(VersionSchema)
 	}
-	if testFlag(c.flags, "modified") || all {
-		data["Modified"] = fmt.Sprint(c.version.Modified())
+	for _, field := range versionFields {
+		if c.selected(field, any) {
+			field.encode(&data, c.version)
+		}
 	}
 
-	m, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("error encoding version information: %w", err)
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, data); err != nil {
+		return "", fmt.Errorf("error encoding version information: %w", err)
 	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
 
-	_, err = fmt.Fprintln(c.out, string(m))
+func (c *versionCmdConfig) selected(field versionField, any bool) bool {
+	if !c.fieldEnabled(field) {
+		return false
+	}
+	return testFlag(c.flags, field.flag) || (field.isDefault && !any)
+}
+
+func (c *versionCmdConfig) fieldEnabled(field versionField) bool {
+	return len(c.fields) == 0 || slices.Contains(c.fields, field.id)
+}
+
+// versionField describes a piece of version information and how it is
+// rendered by the version command.
+type versionField struct {
+	id VersionField
+
+	// flag is the name of the flag selecting the field
+	flag string
+	// short is the shorthand of the flag
+	short string
+	// usage is the usage string of the flag
+	usage string
+	// key is the name of the field in key=value output
+	key string
+	// isDefault marks the field shown when no field is selected explicitly
+	isDefault bool
+
+	// value renders the field in key=value output
+	value func(VersionInfo) string
+
+	// encode sets the field in encoded output
+	encode func(*versionOutput, VersionInfo)
+	// text renders the field in text output, reporting false if it should
+	// be omitted
+	text func(VersionInfo, *flag.FlagSet) (string, bool)
+}
+
+var versionFields = []versionField{
+	{
+		id:        FieldVersion,
+		flag:      "number",
+		short:     "n",
+		usage:     "print the version number",
+		key:       "version",
+		isDefault: true,
+		value:     VersionInfo.Version,
+		encode: func(out *versionOutput, vi VersionInfo) {
+			out.Version = ptr(vi.Version())
+		},
+		text: textNonEmpty(VersionInfo.Version),
+	},
+	{
+		id:    FieldRevision,
+		flag:  "revision",
+		short: "r",
+		usage: "print the commit revision identifier",
+		key:   "revision",
+		value: VersionInfo.Revision,
+		encode: func(out *versionOutput, vi VersionInfo) {
+			out.Revision = ptr(vi.Revision())
+		},
+		text: textNonEmpty(VersionInfo.Revision),
+	},
+	{
+		id:    FieldTime,
+		flag:  "time",
+		short: "t",
+		usage: "print the commit revision modification time",
+		key:   "time",
+		value: VersionInfo.Time,
+		encode: func(out *versionOutput, vi VersionInfo) {
+			out.Time = ptr(vi.Time())
+		},
+		text: textTime,
+	},
+	{
+		id:    FieldGoVersion,
+		flag:  "go-version",
+		short: "g",
+		usage: "print the Go toolchain version",
+		key:   "go_version",
+		value: VersionInfo.GoVersion,
+		encode: func(out *versionOutput, vi VersionInfo) {
+			out.GoVersion = ptr(vi.GoVersion())
+		},
+		text: textNonEmpty(VersionInfo.GoVersion),
+	},
+	{
+		id:    FieldModified,
+		flag:  "modified",
+		short: "m",
+		usage: "print whether the source tree had local modifications",
+		key:   "modified",
+		value: func(vi VersionInfo) string {
+			return strconv.FormatBool(vi.Modified())
+		},
+		encode: func(out *versionOutput, vi VersionInfo) {
+			out.Modified = ptr(vi.Modified())
+		},
+		text: textModified,
+	},
+}
+
+// versionOutput holds the selected version information in encoded output.
+type versionOutput struct {
+	Schema    *int    `json:"_schema,omitempty"`
+	Version   *string `json:"Version,omitempty"`
+	Revision  *string `json:"Revision,omitempty"`
+	Time      *string `json:"Time,omitempty"`
+	GoVersion *string `json:"GoVersion,omitempty"`
+	Modified  *bool   `json:"Modified,omitempty"`
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// textNonEmpty renders a field as is, omitting it if it is empty.
+func textNonEmpty(value func(VersionInfo) string) func(VersionInfo, *flag.FlagSet) (string, bool) {
+	return func(vi VersionInfo, _ *flag.FlagSet) (string, bool) {
+		v := strings.TrimSpace(value(vi))
+		return v, v != ""
+	}
+}
+
+// textModified renders the modified state, as `(modified)` for a dirty tree
+// or, with the `--verbose` flag, as the name of the state.
+func textModified(vi VersionInfo, fs *flag.FlagSet) (string, bool) {
+	if testFlag(fs, "verbose") {
+		return ModifiedStateOf(vi).String(), true
+	}
+	return "(modified)", vi.Modified()
+}
+
+// textTime formats the commit time using the layout given by the
+// `--time-format` flag, falling back to the raw value if it cannot be parsed.
+func textTime(vi VersionInfo, fs *flag.FlagSet) (string, bool) {
+	raw := vi.Time()
+	if raw == "" {
+		return "", false
+	}
+
+	f := fs.Lookup("time-format")
+	if f == nil || f.Value.String() == "" {
+		return raw, true
+	}
+
+	t, err := time.Parse(time.RFC3339, raw)
 	if err != nil {
-		return fmt.Errorf("error writing version information: %w", err)
+		return raw, true
 	}
-	return nil
+	return t.Format(f.Value.String()), true
 }
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+type stubVersionInfo struct {
+	version   string
+	revision  string
+	time      string
+	modified  bool
+	goVersion string
+}
+
+func (vi stubVersionInfo) Version() string   { return vi.version }
+func (vi stubVersionInfo) Revision() string  { return vi.revision }
+func (vi stubVersionInfo) Time() string      { return vi.time }
+func (vi stubVersionInfo) Modified() bool    { return vi.modified }
+func (vi stubVersionInfo) GoVersion() string { return vi.goVersion }
+
+func TestVersionAllWithoutVCS(t *testing.T) {
+	info := stubVersionInfo{version: "v1.2.3", goVersion: "go1.22.0"}
+
+	var out bytes.Buffer
+	cmd := NewVersionCommand(info, &out)
+	if err := cmd.Parse([]string{"--all"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := cmd.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := strings.TrimSuffix(out.String(), "\n")
+	if want := "v1.2.3 go1.22.0"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if strings.Contains(got, "  ") || got != strings.TrimSpace(got) {
+		t.Errorf("output %q contains blank fields", got)
+	}
+}